@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Характеристики устройств. Набор характеристик задается для категории верхнего
+// уровня и наследуется брендами и моделями; значения хранятся строками в таблице
+// device_attributes, числа — с точкой в качестве разделителя.
+const (
+	attrChoice = "choice"
+	attrNumber = "number"
+)
+
+type AttributeDef struct {
+	Key  string
+	Type string
+	// Допустимые значения для choice
+	Options []string
+	// Значения choice переводятся через каталог: attr.<ключ>.<значение>
+	Labeled bool
+	// Ключ каталога с единицей измерения, например attr.unit.gb; пустой — без единицы
+	Unit string
+	// Допустимый диапазон для number
+	Min, Max float64
+	// Имена характеристики в фильтрах поиска и колонках файлов, кроме самого ключа
+	Aliases []string
+}
+
+var attributeDefs = []AttributeDef{
+	{Key: "storage_gb", Type: attrChoice, Options: []string{"16", "32", "64", "128", "256", "512", "1024"},
+		Unit: "attr.unit.gb", Aliases: []string{"storage", "память"}},
+	{Key: "ram_gb", Type: attrChoice, Options: []string{"2", "3", "4", "6", "8", "12", "16"},
+		Unit: "attr.unit.gb", Aliases: []string{"ram", "озу"}},
+	{Key: "color", Type: attrChoice, Labeled: true,
+		Options: []string{"black", "white", "silver", "gold", "blue", "red", "green", "purple", "pink", "other"},
+		Aliases: []string{"цвет"}},
+	{Key: "battery_health", Type: attrNumber, Min: 0, Max: 100,
+		Unit: "attr.unit.percent", Aliases: []string{"battery", "аккумулятор", "акб"}},
+	{Key: "screen_size", Type: attrNumber, Min: 1, Max: 20,
+		Unit: "attr.unit.inch", Aliases: []string{"screen", "экран", "диагональ"}},
+	{Key: "sim_type", Type: attrChoice, Labeled: true, Options: []string{"nano", "dual_nano", "nano_esim", "esim", "none"},
+		Aliases: []string{"sim", "сим"}},
+	{Key: "strap_size", Type: attrChoice, Labeled: true, Options: []string{"xs", "s", "m", "l", "xl"},
+		Aliases: []string{"strap", "ремешок"}},
+}
+
+// Характеристики категорий верхнего уровня в порядке шагов мастера публикации
+var categoryAttributes = map[string][]string{
+	CategorySmartphone: {"storage_gb", "ram_gb", "color", "battery_health", "sim_type"},
+	CategoryTablet:     {"storage_gb", "ram_gb", "color", "screen_size", "sim_type"},
+	CategorySmartwatch: {"color", "strap_size", "battery_health"},
+}
+
+func findAttribute(name string) (AttributeDef, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, def := range attributeDefs {
+		if def.Key == name {
+			return def, true
+		}
+		for _, alias := range def.Aliases {
+			if alias == name {
+				return def, true
+			}
+		}
+	}
+	return AttributeDef{}, false
+}
+
+// categorySchema возвращает характеристики ближайшего узла дерева, для которого они заданы
+func categorySchema(category string) []AttributeDef {
+	path := categoryList.Path(category)
+	for i := len(path) - 1; i >= 0; i-- {
+		keys, ok := categoryAttributes[path[i].Code]
+		if !ok {
+			continue
+		}
+		schema := make([]AttributeDef, 0, len(keys))
+		for _, key := range keys {
+			if def, ok := findAttribute(key); ok {
+				schema = append(schema, def)
+			}
+		}
+		return schema
+	}
+	return nil
+}
+
+// normalizeAttributeValue приводит ввод пользователя к хранимому значению: вариант
+// choice можно указать кодом или названием на любом языке, число — с запятой или
+// с единицей измерения («87 %», «6,1″»)
+func normalizeAttributeValue(def AttributeDef, input string) (string, bool) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if def.Type == attrChoice {
+		if option, ok := matchAttributeOption(def, input); ok {
+			return option, true
+		}
+		// «128гб» и «128 GB» означают вариант 128. Числом разбираются только
+		// числовые варианты: у подписей вроде «2 × nano-SIM» число — часть названия
+		if number, ok := parseAttributeNumber(input); ok && hasNumericOptions(def) {
+			return matchAttributeOption(def, strconv.FormatFloat(number, 'f', -1, 64))
+		}
+		return "", false
+	}
+
+	number, ok := parseAttributeNumber(input)
+	if !ok || number < def.Min || number > def.Max {
+		return "", false
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64), true
+}
+
+// matchAttributeOption ищет вариант choice по коду или названию на любом языке
+func matchAttributeOption(def AttributeDef, input string) (string, bool) {
+	for _, option := range def.Options {
+		if input == option {
+			return option, true
+		}
+		for _, lang := range SupportedLanguages {
+			if input == strings.ToLower(formatAttributeValue(lang, def, option)) {
+				return option, true
+			}
+		}
+	}
+	return "", false
+}
+
+func hasNumericOptions(def AttributeDef) bool {
+	for _, option := range def.Options {
+		if _, err := strconv.ParseFloat(option, 64); err != nil {
+			return false
+		}
+	}
+	return len(def.Options) > 0
+}
+
+func parseAttributeNumber(input string) (float64, bool) {
+	input = strings.TrimRightFunc(strings.TrimSpace(input), func(r rune) bool {
+		return !(r >= '0' && r <= '9')
+	})
+	number, err := strconv.ParseFloat(strings.ReplaceAll(input, ",", "."), 64)
+	return number, err == nil
+}
+
+// validateAttributes проверяет характеристики объявления по схеме его категории и
+// возвращает нормализованные значения; при ошибке — ключ характеристики с ошибкой
+func validateAttributes(category string, attributes map[string]string) (map[string]string, string) {
+	if len(attributes) == 0 {
+		return nil, ""
+	}
+	schema := categorySchema(category)
+	result := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		def, ok := findAttribute(name)
+		if !ok || !schemaHas(schema, def.Key) {
+			return nil, name
+		}
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		normalized, ok := normalizeAttributeValue(def, value)
+		if !ok {
+			return nil, name
+		}
+		result[def.Key] = normalized
+	}
+	return result, ""
+}
+
+func schemaHas(schema []AttributeDef, key string) bool {
+	for _, def := range schema {
+		if def.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attributeName(lang, key string) string {
+	return T(lang, "attr."+key)
+}
+
+func formatAttributeValue(lang string, def AttributeDef, value string) string {
+	if def.Labeled {
+		return T(lang, "attr."+def.Key+"."+value)
+	}
+	if def.Unit == "" {
+		return value
+	}
+	// Единица измерения в каталоге содержит нужный пробел: «128 ГБ», но «87%»
+	return value + T(lang, def.Unit)
+}
+
+type attributeView struct {
+	Name  string
+	Value string
+}
+
+// attributeViews возвращает характеристики для карточки в порядке схемы категории
+func attributeViews(lang string, device Device) []attributeView {
+	if len(device.Attributes) == 0 {
+		return nil
+	}
+	var views []attributeView
+	for _, def := range categorySchema(device.Category) {
+		if value, ok := device.Attributes[def.Key]; ok {
+			views = append(views, attributeView{Name: attributeName(lang, def.Key), Value: formatAttributeValue(lang, def, value)})
+		}
+	}
+	return views
+}
+
+// encodeAttributes и decodeAttributes переводят характеристики в одну колонку CSV:
+// storage_gb=128;color=black
+func encodeAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + attributes[key]
+	}
+	return strings.Join(pairs, ";")
+}
+
+func decodeAttributes(value string) map[string]string {
+	attributes := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			attributes[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// searchFilter — условие поиска из запроса: память:128, озу>=6, состояние>=хорошее
+type searchFilter func(Device) bool
+
+var filterOperators = []string{">=", "<=", ":", "=", ">", "<"}
+
+// parseSearchQuery отделяет фильтры от текста запроса. Слово, похожее на фильтр,
+// но с неизвестным именем или значением, остается в тексте.
+func parseSearchQuery(query string, rates *CurrencyRates, currency string) (string, []searchFilter) {
+	var words []string
+	var filters []searchFilter
+	for _, word := range strings.Fields(query) {
+		if filter, ok := parseSearchFilter(word, rates, currency); ok {
+			filters = append(filters, filter)
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), filters
+}
+
+func parseSearchFilter(word string, rates *CurrencyRates, currency string) (searchFilter, bool) {
+	for _, op := range filterOperators {
+		name, value, ok := strings.Cut(word, op)
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		if op == ":" {
+			op = "="
+		}
+		if def, ok := findAttribute(name); ok {
+			return attributeFilter(def, op, value)
+		}
+		if filter, ok := conditionFilter(strings.ToLower(name), op, value); ok {
+			return filter, true
+		}
+		if filter, ok := cityFilter(strings.ToLower(name), op, value); ok {
+			return filter, true
+		}
+		if filter, ok := priceFilter(rates, currency, strings.ToLower(name), op, value); ok {
+			return filter, true
+		}
+		return deliveryFilter(strings.ToLower(name), op, value)
+	}
+	return nil, false
+}
+
+func attributeFilter(def AttributeDef, op, value string) (searchFilter, bool) {
+	if op == "=" {
+		normalized, ok := normalizeAttributeValue(def, value)
+		return func(device Device) bool {
+			actual, ok := device.Attributes[def.Key]
+			return ok && actual == normalized
+		}, ok
+	}
+
+	wanted, ok := parseAttributeNumber(value)
+	if !ok {
+		return nil, false
+	}
+	return func(device Device) bool {
+		actual, ok := parseAttributeNumber(device.Attributes[def.Key])
+		return ok && compareNumbers(actual, op, wanted)
+	}, true
+}
+
+func compareNumbers(actual float64, op string, wanted float64) bool {
+	switch op {
+	case ">=":
+		return actual >= wanted
+	case "<=":
+		return actual <= wanted
+	case ">":
+		return actual > wanted
+	case "<":
+		return actual < wanted
+	}
+	return actual == wanted
+}
+
+func matchesFilters(device Device, filters []searchFilter) bool {
+	for _, filter := range filters {
+		if !filter(device) {
+			return false
+		}
+	}
+	return true
+}
+
+// getAttributeKeyboard предлагает варианты choice кнопками; число вводится сообщением
+func getAttributeKeyboard(lang string, def AttributeDef) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+
+	if def.Type == attrChoice {
+		for _, option := range def.Options {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(formatAttributeValue(lang, def, option), "attr_"+option))
+			if len(row) == 3 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	skipButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.skip"), "attr_skip")
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{skipButton})
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// formatAttributeHint подсказывает в вопросе мастера, что можно ввести
+func formatAttributeHint(lang string, def AttributeDef) string {
+	if def.Type == attrNumber {
+		return T(lang, "attr.hint.number", fmt.Sprint(def.Min), fmt.Sprint(def.Max))
+	}
+	return T(lang, "attr.hint.choice")
+}
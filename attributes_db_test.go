@@ -0,0 +1,35 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceAttributesRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+
+	id, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", Status: DeviceStatusActive,
+		Attributes: map[string]string{"storage_gb": "128", "color": "black"}})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+
+	device, found, err := db.GetDeviceByID(id)
+	if err != nil || !found {
+		t.Fatalf("GetDeviceByID: %v, %v", found, err)
+	}
+	if len(device.Attributes) != 2 || device.Attributes["storage_gb"] != "128" || device.Attributes["color"] != "black" {
+		t.Fatalf("характеристики после чтения: %v", device.Attributes)
+	}
+
+	if err := db.RemoveDevice(id); err != nil {
+		t.Fatalf("RemoveDevice: %v", err)
+	}
+}
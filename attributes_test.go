@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestValidateAttributes(t *testing.T) {
+	categoryList.Set(defaultCategories())
+
+	got, bad := validateAttributes("iphone_13", map[string]string{"память": "128 ГБ", "color": "Черный", "акб": "87,5%"})
+	if bad != "" || got["storage_gb"] != "128" || got["color"] != "black" || got["battery_health"] != "87.5" {
+		t.Fatalf("validateAttributes = %v, %q", got, bad)
+	}
+	// Размер ремешка не входит в схему смартфонов
+	if _, bad := validateAttributes("iphone_13", map[string]string{"strap_size": "m"}); bad != "strap_size" {
+		t.Errorf("чужая характеристика: %q", bad)
+	}
+	if _, bad := validateAttributes(CategorySmartphone, map[string]string{"battery_health": "120"}); bad != "battery_health" {
+		t.Errorf("значение вне диапазона: %q", bad)
+	}
+}
+
+func TestNormalizeChoiceValue(t *testing.T) {
+	simType, _ := findAttribute("sim_type")
+	storage, _ := findAttribute("storage_gb")
+	for _, c := range []struct {
+		def   AttributeDef
+		input string
+		want  string
+	}{
+		// Число в подписи не должно обрезать ее до «2»
+		{simType, "2 × nano-SIM", "dual_nano"},
+		{simType, " nano-SIM + eSIM ", "nano_esim"},
+		{simType, "Только eSIM", "esim"},
+		{simType, "dual_nano", "dual_nano"},
+		{simType, "2", ""},
+		{storage, "128 ГБ", "128"},
+		{storage, "256gb", "256"},
+		{storage, "128", "128"},
+		{storage, "100", ""},
+	} {
+		got, ok := normalizeAttributeValue(c.def, c.input)
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("%s %q = %q, %v; ожидалось %q", c.def.Key, c.input, got, ok, c.want)
+		}
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	text, filters := parseSearchQuery("iphone память:128 акб>=85 цвет:розовый2", nil, "")
+	if text != "iphone цвет:розовый2" || len(filters) != 2 {
+		t.Fatalf("parseSearchQuery = %q, %+v", text, filters)
+	}
+
+	device := Device{Attributes: map[string]string{"storage_gb": "128", "battery_health": "90"}}
+	if !matchesFilters(device, filters) {
+		t.Error("объявление должно подходить под фильтры")
+	}
+	device.Attributes["battery_health"] = "80"
+	if matchesFilters(device, filters) {
+		t.Error("аккумулятор 80% не подходит под акб>=85")
+	}
+	if matchesFilters(Device{}, filters) {
+		t.Error("объявление без характеристик не подходит под фильтр")
+	}
+}
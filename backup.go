@@ -0,0 +1,276 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	backupFilePrefix = "marketplace-"
+	backupFileSuffix = ".db"
+	backupTimeLayout = "20060102-150405"
+
+	// Страниц за один шаг копирования: между шагами бот может писать в базу
+	backupPagesPerStep = 256
+	backupStepPause    = 10 * time.Millisecond
+)
+
+// copySQLite копирует базу src в dst через онлайн-резервирование SQLite: копия
+// согласована, даже если бот продолжает писать в базу
+func copySQLite(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			dstSQLite, ok := dstRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errBackupUnsupported
+			}
+			srcSQLite, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errBackupUnsupported
+			}
+
+			backup, err := dstSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := backup.Step(backupPagesPerStep)
+				if err != nil {
+					backup.Close()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+				time.Sleep(backupStepPause)
+			}
+		})
+	})
+}
+
+// Backup сохраняет копию базы в файл path. Копия сначала пишется во временный файл,
+// поэтому прерванное копирование не оставляет в каталоге неполных файлов.
+func (d *Database) Backup(path string) error {
+	if d.dialect.name != sqliteDialect.name {
+		return errBackupUnsupported
+	}
+
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+
+	dst, err := sql.Open(sqliteDialect.driver, tmpPath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл копии: %v", err)
+	}
+	err = copySQLite(context.Background(), dst, d.db)
+	dst.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось скопировать базу: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось сохранить копию: %v", err)
+	}
+	return nil
+}
+
+// Restore заменяет содержимое базы копией из файла path. Копия предварительно
+// проверяется, чтобы поврежденный файл не затер рабочую базу.
+func (d *Database) Restore(path string) error {
+	if d.dialect.name != sqliteDialect.name {
+		return errBackupUnsupported
+	}
+
+	src, err := openBackupFile(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := verifyBackup(src); err != nil {
+		return fmt.Errorf("копия %s не прошла проверку: %v", path, err)
+	}
+
+	// Подготовленные запросы ссылаются на старую схему и после замены базы недействительны
+	d.stmtMu.Lock()
+	for query, stmt := range d.stmts {
+		stmt.Close()
+		delete(d.stmts, query)
+	}
+	d.stmtMu.Unlock()
+
+	if err := copySQLite(context.Background(), d.db, src); err != nil {
+		return fmt.Errorf("не удалось восстановить базу: %v", err)
+	}
+	return nil
+}
+
+func openBackupFile(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("файл копии недоступен: %v", err)
+	}
+	db, err := sql.Open(sqliteDialect.driver, "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть копию: %v", err)
+	}
+	return db, nil
+}
+
+// verifyBackup проверяет целостность файла и наличие таблиц маркетплейса
+func verifyBackup(db *sql.DB) error {
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("нарушена целостность: %s", result)
+	}
+
+	for _, table := range []string{"users", "devices"} {
+		var count int
+		if err := db.QueryRow(sqliteDialect.hasTableQuery, table).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("нет таблицы %s", table)
+		}
+	}
+	return nil
+}
+
+// createBackup делает копию базы в каталоге резервных копий и удаляет лишние старые
+func createBackup(db *Database, config BackupConfig) (string, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("не удалось создать каталог копий: %v", err)
+	}
+
+	name := backupFilePrefix + time.Now().Format(backupTimeLayout) + backupFileSuffix
+	path := filepath.Join(config.Dir, name)
+	if err := db.Backup(path); err != nil {
+		return "", err
+	}
+
+	if err := rotateBackups(config.Dir, config.Keep); err != nil {
+		log.Printf("Не удалось удалить старые резервные копии: %v", err)
+	}
+	return path, nil
+}
+
+// listBackups возвращает копии из каталога от старых к новым: время в имени
+// файла записано так, что порядок имен совпадает с порядком создания
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func rotateBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	names, err := listBackups(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// runBackupSchedule делает резервные копии каждые interval_hours часов
+func runBackupSchedule(db *Database, config BackupConfig) {
+	ticker := time.NewTicker(time.Duration(config.IntervalHours) * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		path, err := createBackup(db, config)
+		if err != nil {
+			log.Printf("Ошибка резервного копирования: %v", err)
+			continue
+		}
+		log.Printf("Резервная копия базы сохранена в %s", path)
+	}
+}
+
+// runBackupCommand выполняет служебные команды backup и restore <файл>. Восстановление
+// нужно запускать при остановленном боте; перед ним текущая база тоже сохраняется.
+func runBackupCommand(config Config, args []string) error {
+	db, err := openDatabase(config.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "backup":
+		path, err := createBackup(db, config.Backup)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Резервная копия сохранена в %s\n", path)
+	case "restore":
+		if len(args) < 2 {
+			return fmt.Errorf("укажите файл копии: restore <файл>")
+		}
+		// Копия текущей базы не участвует в ротации, чтобы ротация не удалила
+		// ни ее, ни восстанавливаемый файл из того же каталога
+		if err := os.MkdirAll(config.Backup.Dir, 0o755); err != nil {
+			return fmt.Errorf("не удалось создать каталог копий: %v", err)
+		}
+		current := filepath.Join(config.Backup.Dir, "pre-restore-"+time.Now().Format(backupTimeLayout)+backupFileSuffix)
+		if err := db.Backup(current); err != nil {
+			return fmt.Errorf("не удалось сохранить текущую базу перед восстановлением: %v", err)
+		}
+		fmt.Printf("Текущая база сохранена в %s\n", current)
+
+		if err := db.Restore(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("База восстановлена из %s\n", args[1])
+	default:
+		return fmt.Errorf("неизвестная команда %q, доступны backup и restore", args[0])
+	}
+	return nil
+}
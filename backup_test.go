@@ -0,0 +1,82 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	db := openTestDatabase(t, filepath.Join(dir, "marketplace.db"))
+	config := BackupConfig{Dir: filepath.Join(dir, "backups"), Keep: 2}
+
+	if err := db.SaveUser(User{ID: 1, FirstName: "До копии"}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	path, err := createBackup(db, config)
+	if err != nil {
+		t.Fatalf("createBackup: %v", err)
+	}
+
+	if err := db.SaveUser(User{ID: 2, FirstName: "После копии"}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	if err := db.Restore(path); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	users, err := db.GetUsers()
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if len(users) != 1 || users[1].FirstName != "До копии" {
+		t.Fatalf("после восстановления пользователи = %+v", users)
+	}
+}
+
+func TestRestoreRejectsDamagedBackup(t *testing.T) {
+	dir := t.TempDir()
+	db := openTestDatabase(t, filepath.Join(dir, "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+
+	damaged := filepath.Join(dir, "damaged.db")
+	if err := os.WriteFile(damaged, []byte("это не база SQLite"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Restore(damaged); err == nil {
+		t.Fatal("Restore принял поврежденный файл")
+	}
+
+	if users, err := db.GetUsers(); err != nil || len(users) != 1 {
+		t.Fatalf("рабочая база изменилась: %+v, %v", users, err)
+	}
+}
+
+func TestRotateBackupsKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"marketplace-20240101-000000.db", "marketplace-20240102-000000.db",
+		"marketplace-20240103-000000.db", "pre-restore-20240101-000000.db"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rotateBackups(dir, 2); err != nil {
+		t.Fatalf("rotateBackups: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, names[0])); !os.IsNotExist(err) {
+		t.Fatal("самая старая копия не удалена")
+	}
+	for _, name := range names[1:] {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("удалена копия %s", name)
+		}
+	}
+}
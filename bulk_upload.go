@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Пакетная загрузка для магазинов: проверенный продавец присылает файл CSV или XLSX,
+// бот проверяет каждую строку, показывает предпросмотр и после подтверждения
+// публикует все объявления одной транзакцией.
+const (
+	maxBulkRows      = 500
+	bulkPreviewTTL   = 30 * time.Minute
+	bulkPreviewItems = 10
+)
+
+// Колонки файла; заголовки принимаются на английском и русском
+var bulkColumnAliases = map[string]string{
+	"name":           "name",
+	"название":       "name",
+	"description":    "description",
+	"описание":       "description",
+	"price":          "price",
+	"цена":           "price",
+	"currency":       "currency",
+	"валюта":         "currency",
+	"category":       "category",
+	"категория":      "category",
+	"contact":        "contact",
+	"контакт":        "contact",
+	"контакты":       "contact",
+	"condition":      "condition",
+	"состояние":      "condition",
+	"defects":        "defects",
+	"дефекты":        "defects",
+	"kit":            "kit",
+	"комплект":       "kit",
+	"комплектация":   "kit",
+	"warranty_until": "warranty_until",
+	"warranty":       "warranty_until",
+	"гарантия":       "warranty_until",
+	"гарантия до":    "warranty_until",
+	"imei":           "imei",
+	"city":           "city",
+	"город":          "city",
+	"delivery":       "delivery",
+	"доставка":       "delivery",
+	"получение":      "delivery",
+	"shipping_cost":  "shipping_cost",
+	"цена доставки":  "shipping_cost",
+}
+
+var bulkRequiredColumns = []string{"name", "price", "category"}
+
+type bulkUpload struct {
+	Devices []Device
+	Errors  []importError
+}
+
+func canBulkUpload(config Config, userID int64) bool {
+	role := config.RoleOf(userID)
+	return role == RoleVerified || role == RoleAdmin
+}
+
+func isBulkUploadFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+func readSpreadsheet(name string, content []byte) ([]sheetRow, error) {
+	if strings.ToLower(filepath.Ext(name)) == ".xlsx" {
+		return readXLSXRows(content)
+	}
+	return readCSVRows(content)
+}
+
+// readCSVRows читает CSV с запятой, точкой с запятой или табуляцией: русский Excel
+// сохраняет CSV с разделителем «;»
+func readCSVRows(content []byte) ([]sheetRow, error) {
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectCSVDelimiter(content)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows []sheetRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, sheetRow{Num: line, Cells: record})
+	}
+	return rows, nil
+}
+
+func detectCSVDelimiter(content []byte) rune {
+	firstLine := content
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+
+	delimiter, best := ',', bytes.Count(firstLine, []byte{','})
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > best {
+			delimiter, best = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// parseBulkUpload превращает строки таблицы в объявления продавца. Ошибки строк
+// не прерывают разбор: в отчет попадают номера строк, как их показывает Excel.
+func parseBulkUpload(rows []sheetRow, seller *tgbotapi.User, rates *CurrencyRates, now time.Time) (bulkUpload, error) {
+	var upload bulkUpload
+	if len(rows) == 0 {
+		return upload, fmt.Errorf("файл пуст")
+	}
+
+	columns := make(map[string]int)
+	// Колонки характеристик называются ключом или его синонимом: storage_gb, память, цвет
+	attributeColumns := make(map[string]int)
+	for i, title := range rows[0].Cells {
+		if column, ok := bulkColumnAliases[strings.ToLower(strings.TrimSpace(title))]; ok {
+			columns[column] = i
+		} else if def, ok := findAttribute(title); ok {
+			attributeColumns[def.Key] = i
+		}
+	}
+	for _, column := range bulkRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			return upload, fmt.Errorf("нет колонки %s", column)
+		}
+	}
+
+	defaultContact := ""
+	if seller.UserName != "" {
+		defaultContact = "@" + seller.UserName
+	}
+
+	for _, row := range rows[1:] {
+		if isBlankRow(row.Cells) {
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row.Cells) {
+				return strings.TrimSpace(row.Cells[i])
+			}
+			return ""
+		}
+
+		record := exchangeDevice{
+			Name:          field("name"),
+			Description:   field("description"),
+			Price:         field("price"),
+			Currency:      field("currency"),
+			SellerID:      seller.ID,
+			SellerName:    seller.FirstName,
+			Contact:       field("contact"),
+			Category:      resolveCategoryCode(field("category")),
+			Condition:     field("condition"),
+			Defects:       field("defects"),
+			Kit:           field("kit"),
+			WarrantyUntil: field("warranty_until"),
+			IMEI:          field("imei"),
+			City:          field("city"),
+			Delivery:      field("delivery"),
+			ShippingCost:  field("shipping_cost"),
+		}
+		if record.Contact == "" {
+			record.Contact = defaultContact
+		}
+		for key, i := range attributeColumns {
+			if i < len(row.Cells) && strings.TrimSpace(row.Cells[i]) != "" {
+				if record.Attributes == nil {
+					record.Attributes = make(map[string]string)
+				}
+				record.Attributes[key] = row.Cells[i]
+			}
+		}
+
+		device, key, args := parseExchangeDevice(record, rates, now)
+		// Импорт принимает любую существующую категорию, а новые объявления — только включенную
+		if key == "" && !categoryList.Available(device.Category) {
+			key, args = "import.error.bad_category", []interface{}{field("category")}
+		}
+		if key == "" && device.Contact == "" {
+			key = "bulk.error.no_contact"
+		}
+		if key != "" {
+			upload.Errors = append(upload.Errors, importError{Row: row.Num, Key: key, Args: args})
+			continue
+		}
+		upload.Devices = append(upload.Devices, device)
+	}
+	return upload, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveCategoryCode принимает код включенной категории или ее название на любом языке
+func resolveCategoryCode(value string) string {
+	if category, ok := categoryList.Resolve(value); ok && categoryList.Available(category.Code) {
+		return category.Code
+	}
+	return value
+}
+
+// formatBulkPreview показывает найденные объявления и ошибки; quotaLeft — сколько
+// объявлений продавец может разместить по лимитам, -1 означает отсутствие ограничений
+func formatBulkPreview(lang string, upload bulkUpload, quotaLeft int) string {
+	var builder strings.Builder
+	builder.WriteString(T(lang, "bulk.preview.summary", len(upload.Devices), len(upload.Errors)))
+
+	for i, device := range upload.Devices {
+		if i == bulkPreviewItems {
+			builder.WriteString("\n" + T(lang, "bulk.preview.more", len(upload.Devices)-bulkPreviewItems))
+			break
+		}
+		builder.WriteString(fmt.Sprintf("\n• %s — %s", device.Name, formatPrice(device.Price, device.Currency)))
+	}
+
+	for i, e := range upload.Errors {
+		if i == maxReportErrors {
+			builder.WriteString("\n" + T(lang, "import.report.more_errors", len(upload.Errors)-maxReportErrors))
+			break
+		}
+		builder.WriteString("\n" + T(lang, "bulk.preview.error", e.Row, T(lang, e.Key, e.Args...)))
+	}
+
+	switch {
+	case quotaLeft >= 0 && len(upload.Devices) > quotaLeft:
+		builder.WriteString("\n\n" + T(lang, "bulk.quota", len(upload.Devices), quotaLeft))
+	case len(upload.Devices) > 0:
+		builder.WriteString("\n\n" + T(lang, "bulk.preview.confirm"))
+	}
+	return builder.String()
+}
+
+func getBulkPreviewKeyboard(lang string, token, count int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.bulk_publish", count), fmt.Sprintf("bulk_publish_%d", token)),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.bulk_cancel"), fmt.Sprintf("bulk_cancel_%d", token)),
+		),
+	)
+}
+
+// pendingUploads хранит проверенные, но еще не опубликованные пакеты. У каждого пакета
+// свой номер, чтобы кнопка под старым предпросмотром не опубликовала новый файл.
+type pendingUploads struct {
+	mu    sync.Mutex
+	seq   int
+	items map[int64]pendingUpload
+}
+
+type pendingUpload struct {
+	token   int
+	devices []Device
+	created time.Time
+}
+
+func newPendingUploads() *pendingUploads {
+	return &pendingUploads{items: make(map[int64]pendingUpload)}
+}
+
+func (p *pendingUploads) Put(userID int64, devices []Device) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	p.items[userID] = pendingUpload{token: p.seq, devices: devices, created: time.Now()}
+	return p.seq
+}
+
+// Take забирает пакет, если номер совпадает и предпросмотр не устарел
+func (p *pendingUploads) Take(userID int64, token int) ([]Device, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	upload, ok := p.items[userID]
+	if !ok || upload.token != token {
+		return nil, false
+	}
+	delete(p.items, userID)
+	if time.Since(upload.created) > bulkPreviewTTL {
+		return nil, false
+	}
+	return upload.devices, true
+}
+
+// Restore возвращает пакет после неудачной публикации, чтобы ее можно было повторить
+func (p *pendingUploads) Restore(userID int64, token int, devices []Device) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.items[userID]; !ok {
+		p.items[userID] = pendingUpload{token: token, devices: devices, created: time.Now()}
+	}
+}
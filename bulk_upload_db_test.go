@@ -0,0 +1,51 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveDevicesIsAllOrNothing(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+
+	devices := []Device{
+		{Name: "iPhone", SellerID: 1, Price: 100, Currency: CurrencyRUB, Category: CategorySmartphone, Status: DeviceStatusActive},
+		// Продавца нет в users: внешний ключ откатывает весь пакет
+		{Name: "Pixel", SellerID: 2, Price: 100, Currency: CurrencyRUB, Category: CategorySmartphone, Status: DeviceStatusActive},
+	}
+	if _, err := db.SaveDevices(devices); err == nil {
+		t.Fatal("SaveDevices с несуществующим продавцом должен завершиться ошибкой")
+	}
+	if all, err := db.GetAllDevices(); err != nil || len(all) != 0 {
+		t.Fatalf("после ошибки в базе остались объявления: %+v, %v", all, err)
+	}
+
+	ids, err := db.SaveDevices(devices[:1])
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("SaveDevices: %v, %v", ids, err)
+	}
+}
+
+func TestAddDevicesChecksRowsOfTheSameBatch(t *testing.T) {
+	state := newTestBotState(t)
+	row := Device{Name: "iPhone 13", SellerID: 7, SellerName: "Магазин", Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", IMEI: "490154203237518"}
+
+	added, err := state.AddDevices([]Device{row, row})
+	if err != nil {
+		t.Fatalf("AddDevices: %v", err)
+	}
+	if added[0].Status != DeviceStatusActive || added[0].IMEIStatus != IMEIStatusVerified {
+		t.Errorf("первая строка: %+v", added[0])
+	}
+	if added[1].Status != DeviceStatusModeration || added[1].IMEIStatus != IMEIStatusFlagged ||
+		added[1].ModerationNote != "IMEI повторяется в загруженном файле" {
+		t.Errorf("повтор IMEI в том же файле: %+v", added[1])
+	}
+}
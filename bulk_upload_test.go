@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseBulkUploadCSV(t *testing.T) {
+	content := "\ufeffНазвание;Цена;Валюта;Категория;Описание\n" +
+		"iPhone 13;59 999,90;RUB;Смартфоны;Гарантия\n" +
+		";1000;RUB;smartphone;\n" +
+		"\n" +
+		"Galaxy Tab;199.99;USD;планшеты;\n" +
+		"Pixel;дорого;RUB;smartphone;\n"
+
+	rows, err := readSpreadsheet("shop.csv", []byte(content))
+	if err != nil {
+		t.Fatalf("readSpreadsheet: %v", err)
+	}
+	seller := &tgbotapi.User{ID: 7, FirstName: "Магазин", UserName: "shop"}
+	rates := &CurrencyRates{Base: CurrencyRUB, Rates: map[string]float64{CurrencyRUB: 1, CurrencyUSD: 90}}
+	upload, err := parseBulkUpload(rows, seller, rates, time.Now())
+	if err != nil {
+		t.Fatalf("parseBulkUpload: %v", err)
+	}
+
+	if len(upload.Devices) != 2 {
+		t.Fatalf("объявления = %+v", upload.Devices)
+	}
+	iphone := upload.Devices[0]
+	if iphone.Price != 5999990 || iphone.Category != CategorySmartphone || iphone.Contact != "@shop" || iphone.SellerID != 7 {
+		t.Fatalf("первое объявление = %+v", iphone)
+	}
+	if upload.Devices[1].Category != CategoryTablet || upload.Devices[1].Currency != CurrencyUSD {
+		t.Fatalf("второе объявление = %+v", upload.Devices[1])
+	}
+
+	// Номера строк совпадают с номерами в таблице: заголовок — строка 1
+	want := []importError{{Row: 3, Key: "import.error.no_name"}, {Row: 6, Key: "import.error.bad_price"}}
+	if len(upload.Errors) != len(want) {
+		t.Fatalf("ошибки = %+v", upload.Errors)
+	}
+	for i, e := range upload.Errors {
+		if e.Row != want[i].Row || e.Key != want[i].Key {
+			t.Errorf("ошибка %d = %+v, ожидалась %+v", i, e, want[i])
+		}
+	}
+
+	// Если лимиты не пускают весь файл, предпросмотр сообщает об этом вместо вопроса о публикации
+	if preview := formatBulkPreview(LangRU, upload, -1); !strings.Contains(preview, T(LangRU, "bulk.preview.confirm")) {
+		t.Errorf("предпросмотр без лимитов: %q", preview)
+	}
+	if preview := formatBulkPreview(LangRU, upload, 1); !strings.Contains(preview, T(LangRU, "bulk.quota", 2, 1)) ||
+		strings.Contains(preview, T(LangRU, "bulk.preview.confirm")) {
+		t.Errorf("предпросмотр сверх лимита: %q", preview)
+	}
+}
+
+func TestReadXLSXRows(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Лист1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>name</t></si><si><t>price</t></si><si><r><t>iPhone </t></r><r><t>13</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+			<row r="4"><c r="A4" t="s"><v>2</v></c><c r="C4"><v>14999.899999999999</v></c></row>
+			</sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+
+	rows, err := readSpreadsheet("shop.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("readSpreadsheet: %v", err)
+	}
+	if len(rows) != 2 || rows[1].Num != 4 {
+		t.Fatalf("строки = %+v", rows)
+	}
+	if got := rows[1].Cells; len(got) != 3 || got[0] != "iPhone 13" || got[1] != "" || got[2] != "14999.9" {
+		t.Fatalf("ячейки = %q", got)
+	}
+}
@@ -1,25 +1,410 @@
-package main
-
-const (
-	CategorySmartphone = "smartphone"
-	CategoryTablet     = "tablet"
-	CategorySmartwatch = "smartwatch"
-	CategoryAccessory  = "accessory"
-	CategoryOther      = "other"
-)
-
-var CategoryNames = map[string]string{
-	CategorySmartphone: "Смартфоны",
-	CategoryTablet:     "Планшеты",
-	CategorySmartwatch: "Умные часы",
-	CategoryAccessory:  "Аксессуары",
-	CategoryOther:      "Другое",
-}
-
-var Categories = []string{
-	CategorySmartphone,
-	CategoryTablet,
-	CategorySmartwatch,
-	CategoryAccessory,
-	CategoryOther,
-} 
\ No newline at end of file
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Коды встроенных категорий: с ними создается таблица categories, и они же
+// используются, когда бот работает без базы данных
+const (
+	CategorySmartphone = "smartphone"
+	CategoryTablet     = "tablet"
+	CategorySmartwatch = "smartwatch"
+	CategoryAccessory  = "accessory"
+	CategoryOther      = "other"
+)
+
+type Category struct {
+	Code string
+	// Код родительского узла дерева: Смартфоны → Apple → iPhone 13; пустой у корневых категорий
+	Parent string
+	// Название на каждом из поддерживаемых языков
+	Names     map[string]string
+	Emoji     string
+	SortOrder int
+	// Выключенная категория пропадает из клавиатур, но объявления в ней остаются
+	Enabled bool
+}
+
+// Name возвращает название на языке lang, а если перевода нет — на языке по умолчанию
+func (c Category) Name(lang string) string {
+	if name := c.Names[lang]; name != "" {
+		return name
+	}
+	if name := c.Names[defaultLanguage]; name != "" {
+		return name
+	}
+	return c.Code
+}
+
+func (c Category) Label(lang string) string {
+	if c.Emoji == "" {
+		return c.Name(lang)
+	}
+	return c.Emoji + " " + c.Name(lang)
+}
+
+func defaultCategories() []Category {
+	return []Category{
+		{Code: CategorySmartphone, Emoji: "📱", SortOrder: 1, Enabled: true,
+			Names: map[string]string{LangRU: "Смартфоны", LangEN: "Smartphones"}},
+		{Code: CategoryTablet, Emoji: "📲", SortOrder: 2, Enabled: true,
+			Names: map[string]string{LangRU: "Планшеты", LangEN: "Tablets"}},
+		{Code: CategorySmartwatch, Emoji: "⌚", SortOrder: 3, Enabled: true,
+			Names: map[string]string{LangRU: "Умные часы", LangEN: "Smartwatches"}},
+		{Code: CategoryAccessory, Emoji: "🎧", SortOrder: 4, Enabled: true,
+			Names: map[string]string{LangRU: "Аксессуары", LangEN: "Accessories"}},
+		{Code: CategoryOther, Emoji: "📦", SortOrder: 5, Enabled: true,
+			Names: map[string]string{LangRU: "Другое", LangEN: "Other"}},
+
+		catalogNode("smartphone_apple", CategorySmartphone, "Apple", 1),
+		catalogNode("iphone_13", "smartphone_apple", "iPhone 13", 1),
+		catalogNode("iphone_14", "smartphone_apple", "iPhone 14", 2),
+		catalogNode("iphone_15", "smartphone_apple", "iPhone 15", 3),
+		catalogNode("smartphone_samsung", CategorySmartphone, "Samsung", 2),
+		catalogNode("galaxy_s23", "smartphone_samsung", "Galaxy S23", 1),
+		catalogNode("galaxy_a54", "smartphone_samsung", "Galaxy A54", 2),
+		catalogNode("smartphone_xiaomi", CategorySmartphone, "Xiaomi", 3),
+		catalogNode("redmi_note_12", "smartphone_xiaomi", "Redmi Note 12", 1),
+		catalogNode("tablet_apple", CategoryTablet, "Apple", 1),
+		catalogNode("ipad", "tablet_apple", "iPad", 1),
+		catalogNode("ipad_air", "tablet_apple", "iPad Air", 2),
+		catalogNode("ipad_pro", "tablet_apple", "iPad Pro", 3),
+		catalogNode("tablet_samsung", CategoryTablet, "Samsung", 2),
+		catalogNode("galaxy_tab_s9", "tablet_samsung", "Galaxy Tab S9", 1),
+		catalogNode("smartwatch_apple", CategorySmartwatch, "Apple", 1),
+		catalogNode("apple_watch_9", "smartwatch_apple", "Apple Watch Series 9", 1),
+		catalogNode("apple_watch_se", "smartwatch_apple", "Apple Watch SE", 2),
+		catalogNode("smartwatch_samsung", CategorySmartwatch, "Samsung", 2),
+		catalogNode("galaxy_watch_6", "smartwatch_samsung", "Galaxy Watch 6", 1),
+	}
+}
+
+// catalogNode описывает бренд или модель: их названия одинаковы на всех языках
+func catalogNode(code, parent, name string, order int) Category {
+	return Category{Code: code, Parent: parent, SortOrder: order, Enabled: true,
+		Names: map[string]string{defaultLanguage: name}}
+}
+
+// categoryCatalog — текущий список категорий, из которого строятся клавиатуры и
+// карточки объявлений. BotState заполняет его из базы при запуске и после каждого
+// изменения, сделанного администратором.
+type categoryCatalog struct {
+	mu    sync.RWMutex
+	items []Category
+}
+
+var categoryList = newCategoryCatalog(defaultCategories())
+
+func newCategoryCatalog(items []Category) *categoryCatalog {
+	catalog := &categoryCatalog{}
+	catalog.Set(items)
+	return catalog
+}
+
+func (c *categoryCatalog) Set(items []Category) {
+	sorted := append([]Category(nil), items...)
+	sortCategories(sorted)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = sorted
+}
+
+func (c *categoryCatalog) All() []Category {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Category(nil), c.items...)
+}
+
+// Children возвращает включенные дочерние узлы; parent "" — корневые категории
+func (c *categoryCatalog) Children(parent string) []Category {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var children []Category
+	for _, category := range c.items {
+		if category.Parent == parent && category.Enabled {
+			children = append(children, category)
+		}
+	}
+	return children
+}
+
+func (c *categoryCatalog) Get(code string) (Category, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.get(code)
+}
+
+func (c *categoryCatalog) get(code string) (Category, bool) {
+	for _, category := range c.items {
+		if category.Code == code {
+			return category, true
+		}
+	}
+	return Category{}, false
+}
+
+// Path возвращает цепочку узлов от корневой категории до code включительно
+func (c *categoryCatalog) Path(code string) []Category {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var path []Category
+	// Ограничение глубины защищает от цикла, если дерево в базе испорчено вручную
+	for len(path) <= len(c.items) {
+		category, ok := c.get(code)
+		if !ok {
+			break
+		}
+		path = append([]Category{category}, path...)
+		if category.Parent == "" {
+			break
+		}
+		code = category.Parent
+	}
+	return path
+}
+
+// Available сообщает, можно ли выбрать узел: он и все его предки включены
+func (c *categoryCatalog) Available(code string) bool {
+	path := c.Path(code)
+	if len(path) == 0 || path[0].Parent != "" {
+		return false
+	}
+	for _, category := range path {
+		if !category.Enabled {
+			return false
+		}
+	}
+	return true
+}
+
+// Subtree возвращает коды узла и всех его потомков
+func (c *categoryCatalog) Subtree(code string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	codes := []string{code}
+	for i := 0; i < len(codes) && len(codes) <= len(c.items); i++ {
+		for _, category := range c.items {
+			if category.Parent == codes[i] {
+				codes = append(codes, category.Code)
+			}
+		}
+	}
+	return codes
+}
+
+// Counts считает объявления в каждом узле вместе с объявлениями потомков
+func (c *categoryCatalog) Counts(devices []Device) map[string]int {
+	counts := make(map[string]int)
+	for _, device := range devices {
+		for _, category := range c.Path(device.Category) {
+			counts[category.Code]++
+		}
+	}
+	return counts
+}
+
+// ModelName составляет название устройства по выбранной модели: «Apple iPhone 13».
+// Для корневой категории и бренда без модели возвращается пустая строка.
+func (c *categoryCatalog) ModelName(lang, code string) string {
+	path := c.Path(code)
+	if len(path) < 3 {
+		return ""
+	}
+	name := ""
+	for _, category := range path[1:] {
+		part := category.Name(lang)
+		if name != "" && !strings.HasPrefix(part, name) {
+			part = name + " " + part
+		}
+		name = part
+	}
+	return name
+}
+
+// Resolve находит категорию по коду или по названию на любом языке
+func (c *categoryCatalog) Resolve(value string) (Category, bool) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, category := range c.items {
+		if lower == category.Code {
+			return category, true
+		}
+		for _, name := range category.Names {
+			if lower == strings.ToLower(name) {
+				return category, true
+			}
+		}
+	}
+	return Category{}, false
+}
+
+func sortCategories(items []Category) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].SortOrder != items[j].SortOrder {
+			return items[i].SortOrder < items[j].SortOrder
+		}
+		return items[i].Code < items[j].Code
+	})
+}
+
+// Код попадает в callback-данные кнопок (cat_<код>, sort_price_<код>), размер
+// которых Telegram ограничивает 64 байтами
+var categoryCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// applyCategoryCommand разбирает аргументы команды /category и возвращает
+// измененную категорию, которую нужно сохранить:
+//
+//	set <код> <эмодзи или -> <название> | <name>
+//	parent <код> <код родителя или ->
+//	order <код> <число>
+//	on <код>
+//	off <код>
+//
+// При ошибке возвращается ключ сообщения для администратора.
+func applyCategoryCommand(current []Category, args string) (Category, string, []interface{}) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return Category{}, "category.usage", nil
+	}
+	action, code := strings.ToLower(fields[0]), strings.ToLower(fields[1])
+
+	var category Category
+	found := false
+	maxOrder := 0
+	enabledRoots := 0
+	for _, c := range current {
+		if c.Code == code {
+			category, found = c, true
+		}
+		if c.SortOrder > maxOrder {
+			maxOrder = c.SortOrder
+		}
+		if c.Enabled && c.Parent == "" {
+			enabledRoots++
+		}
+	}
+
+	switch action {
+	case "set":
+		if !categoryCodePattern.MatchString(code) {
+			return Category{}, "category.error.bad_code", []interface{}{fields[1]}
+		}
+		if len(fields) < 4 {
+			return Category{}, "category.usage", nil
+		}
+		names := strings.Split(strings.Join(fields[3:], " "), "|")
+		if strings.TrimSpace(names[0]) == "" {
+			return Category{}, "category.error.no_name", nil
+		}
+		if !found {
+			category = Category{Code: code, SortOrder: maxOrder + 1, Enabled: true}
+		}
+		category.Emoji = fields[2]
+		if category.Emoji == "-" {
+			category.Emoji = ""
+		}
+		category.Names = make(map[string]string)
+		for i, lang := range SupportedLanguages {
+			if i < len(names) && strings.TrimSpace(names[i]) != "" {
+				category.Names[lang] = strings.TrimSpace(names[i])
+			}
+		}
+		return category, "", nil
+	case "parent", "order", "on", "off":
+		if !found {
+			return Category{}, "category.error.not_found", []interface{}{code}
+		}
+	default:
+		return Category{}, "category.usage", nil
+	}
+
+	switch action {
+	case "parent":
+		if len(fields) < 3 {
+			return Category{}, "category.usage", nil
+		}
+		parent := strings.ToLower(fields[2])
+		if parent == "-" {
+			parent = ""
+		}
+		if parent != "" {
+			catalog := newCategoryCatalog(current)
+			if _, ok := catalog.Get(parent); !ok {
+				return Category{}, "category.error.not_found", []interface{}{parent}
+			}
+			// Узел нельзя перенести внутрь собственного поддерева
+			for _, descendant := range catalog.Subtree(code) {
+				if descendant == parent {
+					return Category{}, "category.error.cycle", nil
+				}
+			}
+		}
+		if category.Parent == "" && parent != "" && category.Enabled && enabledRoots == 1 {
+			return Category{}, "category.error.last_enabled", nil
+		}
+		category.Parent = parent
+	case "order":
+		if len(fields) < 3 {
+			return Category{}, "category.usage", nil
+		}
+		order, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return Category{}, "category.error.bad_order", []interface{}{fields[2]}
+		}
+		category.SortOrder = order
+	case "on":
+		category.Enabled = true
+	case "off":
+		// Без включенных категорий мастер публикации не сможет завершиться
+		if category.Enabled && category.Parent == "" && enabledRoots == 1 {
+			return Category{}, "category.error.last_enabled", nil
+		}
+		category.Enabled = false
+	}
+	return category, "", nil
+}
+
+// formatCategoryList показывает дерево категорий с отступом по уровню вложенности
+func formatCategoryList(lang string, items []Category) string {
+	var builder strings.Builder
+	builder.WriteString(T(lang, "categories.header"))
+	writeCategoryTree(&builder, lang, items, "", 0)
+	builder.WriteString("\n\n" + T(lang, "category.usage"))
+	return builder.String()
+}
+
+func writeCategoryTree(builder *strings.Builder, lang string, items []Category, parent string, depth int) {
+	if depth > len(items) {
+		return
+	}
+	for _, category := range items {
+		if category.Parent != parent {
+			continue
+		}
+		var names []string
+		for _, l := range SupportedLanguages {
+			if name := category.Names[l]; name != "" {
+				names = append(names, name)
+			}
+		}
+		status := T(lang, "categories.enabled")
+		if !category.Enabled {
+			status = T(lang, "categories.disabled")
+		}
+		builder.WriteString("\n" + strings.Repeat("    ", depth) + T(lang, "categories.item", category.SortOrder,
+			category.Emoji, category.Code, strings.Join(names, " | "), status))
+		writeCategoryTree(builder, lang, items, category.Code, depth+1)
+	}
+}
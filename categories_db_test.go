@@ -0,0 +1,88 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCategoriesTable(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+	// Миграции и встроенный список без базы данных должны совпадать
+	seeded := newCategoryCatalog(categories)
+	if len(categories) != len(defaultCategories()) {
+		t.Fatalf("категорий после миграций %d, встроенных %d", len(categories), len(defaultCategories()))
+	}
+	for _, want := range defaultCategories() {
+		got, ok := seeded.Get(want.Code)
+		if !ok || got.Parent != want.Parent || got.Name(LangEN) != want.Name(LangEN) || got.SortOrder != want.SortOrder || !got.Enabled {
+			t.Errorf("категория %s после миграций = %+v, ожидалась %+v", want.Code, got, want)
+		}
+	}
+
+	laptop, key, _ := applyCategoryCommand(categories, "set laptop 💻 Ноутбуки | Laptops")
+	if key != "" {
+		t.Fatalf("set: %s", key)
+	}
+	if err := db.SaveCategory(laptop); err != nil {
+		t.Fatalf("SaveCategory: %v", err)
+	}
+
+	hidden, key, _ := applyCategoryCommand(categories, "off tablet")
+	if key != "" {
+		t.Fatalf("off: %s", key)
+	}
+	if err := db.SaveCategory(hidden); err != nil {
+		t.Fatalf("SaveCategory: %v", err)
+	}
+
+	catalog := newCategoryCatalog(nil)
+	categories, err = db.GetCategories()
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+	catalog.Set(categories)
+
+	got, ok := catalog.Resolve("ноутбуки")
+	if !ok || got.Code != "laptop" || got.Emoji != "💻" || got.Name(LangEN) != "Laptops" || got.SortOrder != 6 {
+		t.Fatalf("Resolve(ноутбуки) = %+v, %v", got, ok)
+	}
+	for _, category := range catalog.Children("") {
+		if category.Code == CategoryTablet {
+			t.Fatal("скрытая категория попала в клавиатуру")
+		}
+	}
+	if tablet, ok := catalog.Get(CategoryTablet); !ok || tablet.Name(LangRU) != "Планшеты" {
+		t.Fatalf("скрытая категория должна оставаться в списке: %+v", tablet)
+	}
+}
+
+func TestGetDevicesByCategorySubtree(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	for _, category := range []string{"iphone_13", "galaxy_s23", CategorySmartphone, "ipad"} {
+		if _, err := db.SaveDevice(Device{Name: category, SellerID: 1, Price: 100, Currency: CurrencyRUB,
+			Category: category, Status: DeviceStatusActive}); err != nil {
+			t.Fatalf("SaveDevice: %v", err)
+		}
+	}
+
+	catalog := newCategoryCatalog(defaultCategories())
+	devices, err := db.GetDevicesByCategory(catalog.Subtree(CategorySmartphone)...)
+	if err != nil || len(devices) != 3 {
+		t.Fatalf("объявления ветки «Смартфоны»: %+v, %v", devices, err)
+	}
+	// Пустой список категорий не превращается в IN ()
+	if devices, err := db.GetDevicesByCategory(); err != nil || len(devices) != 0 {
+		t.Fatalf("без категорий: %+v, %v", devices, err)
+	}
+}
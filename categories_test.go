@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestApplyCategoryCommandErrors(t *testing.T) {
+	only := []Category{{Code: CategoryOther, Enabled: true, Names: map[string]string{LangRU: "Другое"}}}
+
+	cases := map[string]string{
+		"": "category.usage",
+		"set ноутбуки 💻 Ноутбуки": "category.error.bad_code",
+		"set laptop 💻 | Laptops":  "category.error.no_name",
+		"order phone 1":           "category.error.not_found",
+		"order other first":       "category.error.bad_order",
+		"off other":               "category.error.last_enabled",
+	}
+	for args, want := range cases {
+		if _, key, _ := applyCategoryCommand(only, args); key != want {
+			t.Errorf("%q: ошибка %q, ожидалась %q", args, key, want)
+		}
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	catalog := newCategoryCatalog(defaultCategories())
+
+	if name := catalog.ModelName(LangRU, "iphone_13"); name != "Apple iPhone 13" {
+		t.Errorf("ModelName(iphone_13) = %q", name)
+	}
+	// Название модели уже начинается с бренда — бренд не повторяется
+	if name := catalog.ModelName(LangRU, "apple_watch_se"); name != "Apple Watch SE" {
+		t.Errorf("ModelName(apple_watch_se) = %q", name)
+	}
+	if name := catalog.ModelName(LangRU, "smartphone_apple"); name != "" {
+		t.Errorf("для бренда без модели название не подставляется: %q", name)
+	}
+
+	counts := catalog.Counts([]Device{{Category: "iphone_13"}, {Category: "iphone_15"}, {Category: "galaxy_s23"}, {Category: CategoryOther}})
+	if counts[CategorySmartphone] != 3 || counts["smartphone_apple"] != 2 || counts["iphone_13"] != 1 || counts[CategoryTablet] != 0 {
+		t.Errorf("Counts = %v", counts)
+	}
+
+	if _, key, _ := applyCategoryCommand(catalog.All(), "parent smartphone iphone_13"); key != "category.error.cycle" {
+		t.Errorf("перенос категории в собственное поддерево: ошибка %q", key)
+	}
+	moved, key, _ := applyCategoryCommand(catalog.All(), "parent smartphone_xiaomi tablet")
+	if key != "" || moved.Parent != CategoryTablet {
+		t.Fatalf("parent: %+v, %q", moved, key)
+	}
+
+	off, _, _ := applyCategoryCommand(catalog.All(), "off smartphone_apple")
+	items := catalog.All()
+	for i := range items {
+		if items[i].Code == off.Code {
+			items[i] = off
+		}
+	}
+	catalog.Set(items)
+	if catalog.Available("iphone_13") {
+		t.Error("модель скрытого бренда не должна быть доступна для выбора")
+	}
+}
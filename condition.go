@@ -0,0 +1,228 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Состояние устройства: оценка по шкале, отмеченные дефекты, комплектация и срок
+// гарантии. В базе хранятся коды, названия берутся из каталога сообщений:
+// condition.<код>, defect.<код> и kit.<код>.
+
+// Шкала состояния от лучшего к худшему; на этот порядок опирается фильтр состояние>=хорошее
+var conditionGrades = []string{"new", "refurbished", "like_new", "good", "fair", "for_parts"}
+
+var deviceDefects = []string{"cracked_screen", "scratches", "dents", "dead_pixels", "battery_worn",
+	"camera", "buttons", "biometrics", "water_damage"}
+
+var kitItems = []string{"box", "charger", "cable", "receipt"}
+
+// Дата гарантии вводится в привычном виде, в файлах допускается и ISO 8601
+var warrantyDateLayouts = []string{"02.01.2006", "2006-01-02"}
+
+const warrantyDisplayLayout = "02.01.2006"
+
+func conditionRank(grade string) int {
+	for i, g := range conditionGrades {
+		if g == grade {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeChoice находит код по самому коду или по названию на любом языке
+func normalizeChoice(prefix string, options []string, input string) (string, bool) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	for _, option := range options {
+		if input == option {
+			return option, true
+		}
+		for _, lang := range SupportedLanguages {
+			if input == strings.ToLower(T(lang, prefix+option)) {
+				return option, true
+			}
+		}
+	}
+	return "", false
+}
+
+func normalizeCondition(input string) (string, bool) {
+	return normalizeChoice("condition.", conditionGrades, input)
+}
+
+// normalizeChecklist разбирает список через запятую или точку с запятой; пустая
+// строка, «нет» и «-» означают пустой список
+func normalizeChecklist(prefix string, options []string, input string) ([]string, bool) {
+	input = strings.TrimSpace(input)
+	if input == "" || input == "-" || isNoneWord(input) {
+		return nil, true
+	}
+
+	selected := make(map[string]bool)
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ';' }) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		code, ok := normalizeChoice(prefix, options, part)
+		if !ok {
+			return nil, false
+		}
+		selected[code] = true
+	}
+	return orderedChecklist(options, selected), true
+}
+
+// orderedChecklist возвращает отмеченные пункты в порядке списка, чтобы карточка и
+// экспорт не зависели от порядка нажатия кнопок
+func orderedChecklist(options []string, selected map[string]bool) []string {
+	var result []string
+	for _, option := range options {
+		if selected[option] {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// toggleChecklist отмечает пункт или снимает отметку
+func toggleChecklist(options, selected []string, code string) []string {
+	marked := make(map[string]bool)
+	for _, item := range selected {
+		marked[item] = true
+	}
+	marked[code] = !marked[code]
+	return orderedChecklist(options, marked)
+}
+
+// splitCodes разбирает список кодов, сохраненный через запятую
+func splitCodes(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func isNoneWord(input string) bool {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "нет", "no", "none":
+		return true
+	}
+	return false
+}
+
+func parseWarrantyDate(input string) (time.Time, bool) {
+	input = strings.TrimSpace(input)
+	for _, layout := range warrantyDateLayouts {
+		if date, err := time.Parse(layout, input); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// hasWarranty сообщает, действует ли гарантия на дату now включительно
+func hasWarranty(device Device, now time.Time) bool {
+	if device.WarrantyUntil.IsZero() {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return !device.WarrantyUntil.Before(today)
+}
+
+func formatChecklist(lang, prefix string, codes []string) string {
+	names := make([]string, len(codes))
+	for i, code := range codes {
+		names[i] = T(lang, prefix+code)
+	}
+	return strings.Join(names, ", ")
+}
+
+// conditionFilter разбирает фильтры состояния: состояние>=хорошее, дефекты:нет,
+// комплект:коробка, гарантия:да. Имена фильтров — на русском или английском.
+func conditionFilter(name, op, value string) (searchFilter, bool) {
+	switch name {
+	case "condition", "состояние":
+		grade, ok := normalizeCondition(value)
+		if !ok {
+			return nil, false
+		}
+		// Лучшее состояние имеет меньший номер, поэтому сравнение идет в обратную сторону
+		wanted := float64(-conditionRank(grade))
+		return func(device Device) bool {
+			rank := conditionRank(device.Condition)
+			return rank >= 0 && compareNumbers(float64(-rank), op, wanted)
+		}, true
+	}
+	if op != "=" {
+		return nil, false
+	}
+
+	switch name {
+	case "defects", "дефекты":
+		if isNoneWord(value) {
+			return func(device Device) bool { return device.Condition != "" && len(device.Defects) == 0 }, true
+		}
+		defect, ok := normalizeChoice("defect.", deviceDefects, value)
+		return func(device Device) bool { return containsString(device.Defects, defect) }, ok
+	case "kit", "комплект":
+		item, ok := normalizeChoice("kit.", kitItems, value)
+		return func(device Device) bool { return containsString(device.Kit, item) }, ok
+	case "warranty", "гарантия":
+		var want bool
+		switch strings.ToLower(value) {
+		case "да", "yes":
+			want = true
+		case "нет", "no":
+		default:
+			return nil, false
+		}
+		return func(device Device) bool { return hasWarranty(device, time.Now()) == want }, true
+	}
+	return nil, false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getConditionKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, grade := range conditionGrades {
+		button := tgbotapi.NewInlineKeyboardButtonData(T(lang, "condition."+grade), "cond_"+grade)
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{button})
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// getChecklistKeyboard показывает пункты списка с отметками; нажатие переключает
+// пункт (callback <callbackPrefix><код>), кнопка «Готово» — <callbackPrefix>done
+func getChecklistKeyboard(lang, namePrefix, callbackPrefix string, options, selected []string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, option := range options {
+		label := "▫️ " + T(lang, namePrefix+option)
+		if containsString(selected, option) {
+			label = "✅ " + T(lang, namePrefix+option)
+		}
+		button := tgbotapi.NewInlineKeyboardButtonData(label, callbackPrefix+option)
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{button})
+	}
+	doneButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.done"), callbackPrefix+"done")
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{doneButton})
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func getWarrantyKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.no_warranty"), "warranty_none"),
+		),
+	)
+}
@@ -0,0 +1,47 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeviceConditionRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+
+	warranty := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	id, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", Status: DeviceStatusActive, Condition: "good",
+		Defects: []string{"scratches", "battery_worn"}, Kit: []string{"box"}, WarrantyUntil: warranty})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+	plain, err := db.SaveDevice(Device{Name: "iPhone 15", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_15", Status: DeviceStatusActive})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+
+	device, _, err := db.GetDeviceByID(id)
+	if err != nil {
+		t.Fatalf("GetDeviceByID: %v", err)
+	}
+	if device.Condition != "good" || len(device.Defects) != 2 || device.Defects[1] != "battery_worn" ||
+		len(device.Kit) != 1 || device.WarrantyUntil.Format("2006-01-02") != "2030-03-01" {
+		t.Fatalf("состояние после чтения: %+v", device)
+	}
+
+	device, _, err = db.GetDeviceByID(plain)
+	if err != nil {
+		t.Fatalf("GetDeviceByID: %v", err)
+	}
+	if device.Condition != "" || device.Defects != nil || device.Kit != nil || !device.WarrantyUntil.IsZero() {
+		t.Fatalf("объявление без состояния: %+v", device)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConditionFilters(t *testing.T) {
+	now := time.Now()
+	good := Device{Condition: "good", Kit: []string{"box", "charger"}, WarrantyUntil: now.AddDate(0, 1, 0)}
+	fair := Device{Condition: "fair", Defects: []string{"cracked_screen"}, WarrantyUntil: now.AddDate(0, -1, 0)}
+
+	cases := []struct {
+		query      string
+		good, fair bool
+	}{
+		{"состояние>=хорошее", true, false},
+		{"condition<=good", true, true},
+		{"состояние:удовлетворительное", false, true},
+		{"дефекты:нет", true, false},
+		{"defects:cracked_screen", false, true},
+		{"комплект:коробка", true, false},
+		{"гарантия:да", true, false},
+		{"warranty:no", false, true},
+	}
+	for _, c := range cases {
+		text, filters := parseSearchQuery(c.query, nil, "")
+		if text != "" || len(filters) != 1 {
+			t.Errorf("%q: текст %q, фильтров %d", c.query, text, len(filters))
+			continue
+		}
+		if matchesFilters(good, filters) != c.good || matchesFilters(fair, filters) != c.fair {
+			t.Errorf("%q: хорошее %v, удовлетворительное %v", c.query, matchesFilters(good, filters), matchesFilters(fair, filters))
+		}
+	}
+}
+
+func TestParseExchangeCondition(t *testing.T) {
+	var device Device
+	record := exchangeDevice{Condition: "Как новое", Defects: "Царапины; dead_pixels", Kit: "нет", WarrantyUntil: "01.03.2030"}
+	if key, _ := parseExchangeCondition(record, &device); key != "" {
+		t.Fatalf("parseExchangeCondition: %s", key)
+	}
+	// Дефекты упорядочиваются по списку, а не по порядку в файле
+	if device.Condition != "like_new" || len(device.Defects) != 2 || device.Defects[0] != "scratches" || device.Kit != nil ||
+		device.WarrantyUntil.Format("2006-01-02") != "2030-03-01" {
+		t.Fatalf("разобрано: %+v", device)
+	}
+
+	if key, _ := parseExchangeCondition(exchangeDevice{Defects: "scratches, разбит корпус"}, &device); key != "import.error.bad_defects" {
+		t.Errorf("неизвестный дефект: %q", key)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// normalizePhone приводит номер к виду +79001234567: Telegram присылает номер без
+// плюса, а продавцы вводят его с пробелами, скобками и дефисами
+func normalizePhone(input string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, input)
+	if digits == "" {
+		return ""
+	}
+	return "+" + digits
+}
+
+// contactVerified сообщает, что контакт объявления — номер, подтвержденный
+// продавцом через кнопку «Поделиться номером»
+func contactVerified(user User, contact string) bool {
+	if user.Phone == "" || strings.ContainsAny(contact, "@/") {
+		return false
+	}
+	return normalizePhone(contact) == user.Phone
+}
+
+// getContactKeyboard предлагает контакт для объявления: подтвержденный номер или
+// кнопку, которая его запросит, и контакт из прошлого объявления. Кнопка
+// request_contact есть только у обычной клавиатуры, поэтому она не inline.
+func getContactKeyboard(lang string, user User) tgbotapi.ReplyKeyboardMarkup {
+	first := tgbotapi.NewKeyboardButtonContact(T(lang, "button.share_phone"))
+	if user.Phone != "" {
+		first = tgbotapi.NewKeyboardButton(user.Phone)
+	}
+	rows := [][]tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButtonRow(first)}
+	if user.Contact != "" && user.Contact != user.Phone {
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(user.Contact)))
+	}
+	return tgbotapi.NewOneTimeReplyKeyboard(rows...)
+}
@@ -0,0 +1,30 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifiedContactRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1, Contact: "+79001234567", Phone: "+79001234567"}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	users, err := db.GetUsers()
+	if err != nil || users[1].Phone != "+79001234567" || users[1].Contact != "+79001234567" {
+		t.Fatalf("пользователь после чтения: %+v, %v", users[1], err)
+	}
+
+	id, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", Status: DeviceStatusActive, Contact: "+79001234567", ContactVerified: true})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+	device, _, err := db.GetDeviceByID(id)
+	if err != nil || !device.ContactVerified {
+		t.Fatalf("объявление после чтения: %+v, %v", device, err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestContactVerified(t *testing.T) {
+	if got := normalizePhone("8 (900) 123-45-67"); got != "+89001234567" {
+		t.Errorf("normalizePhone = %q", got)
+	}
+	user := User{Phone: normalizePhone("79001234567")}
+	for contact, want := range map[string]bool{
+		"+7 900 123-45-67": true,
+		"79001234567":      true,
+		"+7 900 000-00-00": false,
+		"@seller":          false,
+		"t.me/79001234567": false,
+	} {
+		if got := contactVerified(user, contact); got != want {
+			t.Errorf("contactVerified(%q) = %v", contact, got)
+		}
+	}
+	if contactVerified(User{}, "") {
+		t.Error("пустой контакт без номера считается подтвержденным")
+	}
+
+	// Без подтвержденного номера первая кнопка запрашивает его у Telegram
+	keyboard := getContactKeyboard(LangRU, User{Contact: "@seller"})
+	if len(keyboard.Keyboard) != 2 || !keyboard.Keyboard[0][0].RequestContact || keyboard.Keyboard[1][0].Text != "@seller" {
+		t.Errorf("клавиатура без номера: %+v", keyboard.Keyboard)
+	}
+	keyboard = getContactKeyboard(LangRU, User{Phone: "+79001234567", Contact: "+79001234567"})
+	if len(keyboard.Keyboard) != 1 || keyboard.Keyboard[0][0].RequestContact || keyboard.Keyboard[0][0].Text != "+79001234567" {
+		t.Errorf("клавиатура с номером: %+v", keyboard.Keyboard)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ContentCheckConfig struct {
+	StopWords            []string `json:"stop_words"`
+	StopPatterns         []string `json:"stop_patterns"`
+	BlockLinks           bool     `json:"block_links"`
+	BlockPaymentRequests bool     `json:"block_payment_requests"`
+	LowPriceRatio        float64  `json:"low_price_ratio"`
+	MinPriceSamples      int      `json:"min_price_samples"`
+	DetectDuplicates     bool     `json:"detect_duplicates"`
+	// Локальный список IMEI украденных устройств; пустая строка отключает проверку по списку
+	StolenIMEIFile string `json:"stolen_imei_file"`
+}
+
+func DefaultContentCheckConfig() ContentCheckConfig {
+	return ContentCheckConfig{
+		StopWords:            []string{"казино", "ставки", "гарантированный доход", "заработок без вложений"},
+		BlockLinks:           true,
+		BlockPaymentRequests: true,
+		LowPriceRatio:        0.3,
+		MinPriceSamples:      5,
+		DetectDuplicates:     true,
+		StolenIMEIFile:       "stolen_imei.txt",
+	}
+}
+
+// ContentChecker проверяет объявление перед публикацией.
+// Пустая строка означает, что замечаний нет, иначе возвращается причина отправки на модерацию.
+type ContentChecker interface {
+	Check(device Device, existing []Device) string
+}
+
+type ContentPipeline struct {
+	checkers []ContentChecker
+	imei     IMEIChecker
+}
+
+func NewContentPipeline(config ContentCheckConfig, rates *CurrencyRates) (*ContentPipeline, error) {
+	pipeline := &ContentPipeline{}
+
+	stopWords, err := newStopWordChecker(config.StopWords, config.StopPatterns)
+	if err != nil {
+		return nil, err
+	}
+	pipeline.Add(stopWords)
+
+	if config.BlockLinks {
+		pipeline.Add(linkChecker{})
+	}
+	if config.BlockPaymentRequests {
+		pipeline.Add(paymentRequestChecker{})
+	}
+	if config.LowPriceRatio > 0 {
+		pipeline.Add(lowPriceChecker{ratio: config.LowPriceRatio, minSamples: config.MinPriceSamples, rates: rates})
+	}
+	if config.DetectDuplicates {
+		pipeline.Add(duplicateTextChecker{})
+	}
+	if config.StolenIMEIFile != "" {
+		pipeline.SetIMEIChecker(newFileIMEIChecker(config.StolenIMEIFile))
+	}
+
+	return pipeline, nil
+}
+
+func (p *ContentPipeline) Add(checker ContentChecker) {
+	p.checkers = append(p.checkers, checker)
+}
+
+// SetIMEIChecker заменяет источник проверки IMEI, например на клиент внешнего сервиса
+func (p *ContentPipeline) SetIMEIChecker(checker IMEIChecker) {
+	p.imei = checker
+}
+
+// Review выставляет статус объявления перед публикацией: с замечаниями оно уходит
+// на модерацию. Указанный IMEI проверяется на повторы среди sameIMEI — объявлений
+// с тем же номером в любом статусе, кроме отклоненного, — и по базе украденных устройств.
+func (p *ContentPipeline) Review(device Device, existing, sameIMEI []Device) Device {
+	device.Status = DeviceStatusActive
+	reasons := p.Run(device, existing)
+	if device.IMEI != "" {
+		var checker IMEIChecker
+		if p != nil {
+			checker = p.imei
+		}
+		status, reason := verifyIMEI(checker, device, sameIMEI)
+		device.IMEIStatus = status
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if len(reasons) > 0 {
+		device.Status = DeviceStatusModeration
+		device.ModerationNote = strings.Join(reasons, "; ")
+	}
+	return device
+}
+
+func (p *ContentPipeline) Run(device Device, existing []Device) []string {
+	if p == nil {
+		return nil
+	}
+
+	var reasons []string
+	for _, checker := range p.checkers {
+		if reason := checker.Check(device, existing); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+type stopWordChecker struct {
+	words    []string
+	patterns []*regexp.Regexp
+}
+
+func newStopWordChecker(words, patterns []string) (stopWordChecker, error) {
+	checker := stopWordChecker{}
+	for _, word := range words {
+		if word = strings.TrimSpace(strings.ToLower(word)); word != "" {
+			checker.words = append(checker.words, word)
+		}
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return checker, fmt.Errorf("некорректное регулярное выражение %q: %v", pattern, err)
+		}
+		checker.patterns = append(checker.patterns, re)
+	}
+	return checker, nil
+}
+
+func (c stopWordChecker) Check(device Device, existing []Device) string {
+	text := strings.ToLower(device.Name + "\n" + device.Description)
+	for _, word := range c.words {
+		if strings.Contains(text, word) {
+			return fmt.Sprintf("запрещённое слово «%s»", word)
+		}
+	}
+	for _, re := range c.patterns {
+		if re.MatchString(text) {
+			return fmt.Sprintf("совпадение с шаблоном «%s»", re.String())
+		}
+	}
+	return ""
+}
+
+// \b и [a-z] в Go понимают только ASCII, поэтому границы домена заданы явно:
+// иначе адреса вроде «магазин.рф» не распознаются
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.|t\.me/|(?:^|[^\p{L}\d])[\p{L}\d-]+\.(?:ru|com|net|org|io|me|su|рф)(?:$|[^\p{L}\d]))`)
+
+type linkChecker struct{}
+
+func (linkChecker) Check(device Device, existing []Device) string {
+	if linkPattern.MatchString(device.Name) || linkPattern.MatchString(device.Description) {
+		return "ссылка в тексте объявления"
+	}
+	return ""
+}
+
+var (
+	cardNumberPattern     = regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4}\b`)
+	paymentKeywordPattern = regexp.MustCompile(`(?i)(предоплат|перевод на карту|переведите|оплата на карту|qiwi|ю\s?money|yoomoney|webmoney|usdt|биткоин|bitcoin)`)
+)
+
+type paymentRequestChecker struct{}
+
+func (paymentRequestChecker) Check(device Device, existing []Device) string {
+	if cardNumberPattern.MatchString(device.Description) {
+		return "номер банковской карты в описании"
+	}
+	if match := paymentKeywordPattern.FindString(device.Description); match != "" {
+		return fmt.Sprintf("запрос внешней оплаты («%s»)", match)
+	}
+	return ""
+}
+
+// Цены сравниваются в базовой валюте, поэтому медиана учитывает объявления во всех валютах
+type lowPriceChecker struct {
+	ratio      float64
+	minSamples int
+	rates      *CurrencyRates
+}
+
+func (c lowPriceChecker) Check(device Device, existing []Device) string {
+	var prices []Money
+	for _, other := range existing {
+		if other.Category == device.Category && other.Status == DeviceStatusActive && other.Price > 0 {
+			prices = append(prices, c.rates.ToBase(other.Price, other.Currency))
+		}
+	}
+	if len(prices) == 0 || len(prices) < c.minSamples {
+		return ""
+	}
+
+	median := medianPrice(prices)
+	if price := c.rates.ToBase(device.Price, device.Currency); price < MoneyFromFloat(median.Float()*c.ratio) {
+		return fmt.Sprintf("цена %s подозрительно ниже медианы категории (%s)",
+			formatPrice(price, c.rates.Base), formatPrice(median, c.rates.Base))
+	}
+	return ""
+}
+
+func medianPrice(prices []Money) Money {
+	sorted := append([]Money(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// Короткие описания вроде «новый» совпадают слишком часто, поэтому их не сравниваем
+const minDuplicateTextLength = 30
+
+type duplicateTextChecker struct{}
+
+func (duplicateTextChecker) Check(device Device, existing []Device) string {
+	text := normalizeListingText(device.Description)
+	if len([]rune(text)) < minDuplicateTextLength {
+		return ""
+	}
+	for _, other := range existing {
+		if other.SellerID != device.SellerID && normalizeListingText(other.Description) == text {
+			return fmt.Sprintf("описание совпадает с объявлением #%d другого продавца", other.ID)
+		}
+	}
+	return ""
+}
+
+func normalizeListingText(text string) string {
+	var builder strings.Builder
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		word = strings.TrimFunc(word, func(r rune) bool {
+			return strings.ContainsRune(".,!?;:-—()\"'«»", r)
+		})
+		if word == "" {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(word)
+	}
+	return builder.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentCheckers(t *testing.T) {
+	stopWords, err := newStopWordChecker([]string{" Казино ", ""}, []string{`взлом[а-я]*`})
+	if err != nil {
+		t.Fatalf("newStopWordChecker: %v", err)
+	}
+	if _, err := newStopWordChecker(nil, []string{"("}); err == nil {
+		t.Error("некорректное регулярное выражение принято")
+	}
+
+	rates := &CurrencyRates{Base: CurrencyRUB, Rates: map[string]float64{CurrencyRUB: 1, CurrencyUSD: 90}}
+	lowPrice := lowPriceChecker{ratio: 0.3, minSamples: 3, rates: rates}
+	market := []Device{
+		{ID: 1, Category: CategorySmartphone, Status: DeviceStatusActive, Price: 3000000, Currency: CurrencyRUB},
+		{ID: 2, Category: CategorySmartphone, Status: DeviceStatusActive, Price: 40000, Currency: CurrencyUSD}, // 36 000 ₽
+		{ID: 3, Category: CategorySmartphone, Status: DeviceStatusActive, Price: 5000000, Currency: CurrencyRUB},
+		// Не входят в медиану: другая категория, не опубликовано, без цены
+		{ID: 4, Category: CategoryTablet, Status: DeviceStatusActive, Price: 100, Currency: CurrencyRUB},
+		{ID: 5, Category: CategorySmartphone, Status: DeviceStatusRejected, Price: 100, Currency: CurrencyRUB},
+		{ID: 6, Category: CategorySmartphone, Status: DeviceStatusActive},
+	}
+
+	description := "Продаю телефон в отличном состоянии, полный комплект, без торга"
+	duplicates := []Device{{ID: 9, SellerID: 2, Description: "продаю телефон в отличном состоянии полный комплект без торга!"}}
+
+	for _, c := range []struct {
+		name     string
+		checker  ContentChecker
+		device   Device
+		existing []Device
+		want     string
+	}{
+		{"стоп-слово", stopWords, Device{Name: "Лучшее КАЗИНО"}, nil, "«казино»"},
+		{"шаблон", stopWords, Device{Description: "взломанный аккаунт"}, nil, "шаблоном"},
+		{"без стоп-слов", stopWords, Device{Name: "iPhone 13"}, nil, ""},
+		{"ссылка", linkChecker{}, Device{Description: "подробности на https://example.org"}, nil, "ссылка"},
+		{"канал", linkChecker{}, Device{Name: "пишите t.me/shop"}, nil, "ссылка"},
+		{"домен", linkChecker{}, Device{Description: "магазин shop.ru"}, nil, "ссылка"},
+		{"кириллический домен", linkChecker{}, Device{Description: "заходите на магазин.рф"}, nil, "ссылка"},
+		{"домен рф латиницей", linkChecker{}, Device{Description: "сайт example.рф, там дешевле"}, nil, "ссылка"},
+		{"домен рф прописными", linkChecker{}, Device{Name: "ТЕЛЕФОНЫ.РФ"}, nil, "ссылка"},
+		{"кириллический домен в зоне ru", linkChecker{}, Device{Description: "пишите на мой-сайт.ru."}, nil, "ссылка"},
+		{"без ссылки", linkChecker{}, Device{Description: "версия 2.0, экран 6.1"}, nil, ""},
+		{"номер карты", paymentRequestChecker{}, Device{Description: "карта 4276 1234 5678 9012"}, nil, "карты"},
+		{"предоплата", paymentRequestChecker{}, Device{Description: "Только ПРЕДОПЛАТА"}, nil, "«ПРЕДОПЛАТ»"},
+		{"без оплаты", paymentRequestChecker{}, Device{Description: "оплата при встрече"}, nil, ""},
+		// Медиана 36 000 ₽, порог 10 800 ₽
+		{"низкая цена", lowPrice, Device{Category: CategorySmartphone, Price: 100000, Currency: CurrencyRUB}, market, "ниже медианы"},
+		{"низкая цена в долларах", lowPrice, Device{Category: CategorySmartphone, Price: 10000, Currency: CurrencyUSD}, market, "ниже медианы"},
+		{"обычная цена", lowPrice, Device{Category: CategorySmartphone, Price: 2000000, Currency: CurrencyRUB}, market, ""},
+		{"мало объявлений", lowPrice, Device{Category: CategoryTablet, Price: 1, Currency: CurrencyRUB}, market, ""},
+		{"дубликат", duplicateTextChecker{}, Device{SellerID: 1, Description: description}, duplicates, "#9"},
+		{"свое объявление", duplicateTextChecker{}, Device{SellerID: 2, Description: description}, duplicates, ""},
+		{"короткое описание", duplicateTextChecker{}, Device{SellerID: 1, Description: "новый"}, []Device{{ID: 9, SellerID: 2, Description: "Новый!"}}, ""},
+	} {
+		got := c.checker.Check(c.device, c.existing)
+		if (c.want == "") != (got == "") || !strings.Contains(got, c.want) {
+			t.Errorf("%s: %q, ожидалось %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestContentPipelineReview(t *testing.T) {
+	pipeline, err := NewContentPipeline(DefaultContentCheckConfig(), DefaultCurrencyRates())
+	if err != nil {
+		t.Fatalf("NewContentPipeline: %v", err)
+	}
+	pipeline.SetIMEIChecker(nil)
+
+	device := pipeline.Review(Device{Name: "iPhone 13", Description: "Отличное состояние"}, nil, nil)
+	if device.Status != DeviceStatusActive || device.ModerationNote != "" {
+		t.Errorf("чистое объявление: %+v", device)
+	}
+
+	device = pipeline.Review(Device{Name: "iPhone 13", Description: "Предоплата на карту, подробности на www.example.com"}, nil, nil)
+	if device.Status != DeviceStatusModeration || strings.Count(device.ModerationNote, "; ") != 1 {
+		t.Errorf("объявление с замечаниями: %q", device.ModerationNote)
+	}
+
+	// Без пайплайна объявление публикуется сразу
+	var empty *ContentPipeline
+	if device := empty.Review(Device{Description: "казино"}, nil, nil); device.Status != DeviceStatusActive {
+		t.Errorf("без проверок: %+v", device)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	CurrencyRUB = "RUB"
+	CurrencyUSD = "USD"
+	CurrencyEUR = "EUR"
+	CurrencyKZT = "KZT"
+	CurrencyBYN = "BYN"
+	CurrencyUAH = "UAH"
+
+	baseCurrency = CurrencyRUB
+)
+
+var CurrencySymbols = map[string]string{
+	CurrencyRUB: "₽",
+	CurrencyUSD: "$",
+	CurrencyEUR: "€",
+	CurrencyKZT: "₸",
+	CurrencyBYN: "Br",
+	CurrencyUAH: "₴",
+}
+
+// CurrencyRates хранит курсы валют: сколько единиц базовой валюты стоит одна единица валюты
+type CurrencyRates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func DefaultCurrencyRates() *CurrencyRates {
+	return &CurrencyRates{
+		Base:  baseCurrency,
+		Rates: map[string]float64{baseCurrency: 1},
+	}
+}
+
+// LoadCurrencyRates читает таблицу курсов из JSON-файла вида
+// {"base": "RUB", "rates": {"USD": 92.5, "EUR": 100.1}}.
+// Без файла доступна только базовая валюта.
+func LoadCurrencyRates(path string) (*CurrencyRates, error) {
+	rates := DefaultCurrencyRates()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return rates, nil
+	} else if err != nil {
+		return rates, fmt.Errorf("не удалось прочитать файл курсов валют: %v", err)
+	}
+
+	if err := json.Unmarshal(data, rates); err != nil {
+		return rates, fmt.Errorf("некорректный файл курсов валют: %v", err)
+	}
+
+	rates.Base = normalizeCurrency(rates.Base)
+	if rates.Base == "" {
+		rates.Base = baseCurrency
+	}
+
+	normalized := map[string]float64{rates.Base: 1}
+	for code, rate := range rates.Rates {
+		if rate <= 0 {
+			return rates, fmt.Errorf("некорректный курс %s: %v", code, rate)
+		}
+		normalized[normalizeCurrency(code)] = rate
+	}
+	rates.Rates = normalized
+
+	return rates, nil
+}
+
+func normalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (r *CurrencyRates) Supports(currency string) bool {
+	_, ok := r.Rates[normalizeCurrency(currency)]
+	return ok
+}
+
+// Currencies возвращает доступные валюты: базовую первой, остальные по алфавиту
+func (r *CurrencyRates) Currencies() []string {
+	var codes []string
+	for code := range r.Rates {
+		if code != r.Base {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return append([]string{r.Base}, codes...)
+}
+
+func (r *CurrencyRates) Convert(amount Money, from, to string) (Money, bool) {
+	from, to = normalizeCurrency(from), normalizeCurrency(to)
+	if from == "" {
+		from = r.Base
+	}
+	if to == "" {
+		to = r.Base
+	}
+	if from == to {
+		return amount, true
+	}
+
+	fromRate, ok := r.Rates[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := r.Rates[to]
+	if !ok {
+		return 0, false
+	}
+
+	return MoneyFromFloat(amount.Float() * fromRate / toRate), true
+}
+
+// ToBase приводит цену к базовой валюте, чтобы цены в разных валютах можно было сравнивать.
+// Цены в неизвестной валюте считаются указанными в базовой.
+func (r *CurrencyRates) ToBase(amount Money, currency string) Money {
+	if converted, ok := r.Convert(amount, currency, r.Base); ok {
+		return converted
+	}
+	return amount
+}
+
+// priceFilter — фильтр поиска цена<=30000 или price>=300. Сумма указывается в валюте
+// отображения покупателя (если она не выбрана — в базовой), а цены объявлений в других
+// валютах пересчитываются по курсу так же, как при сортировке.
+func priceFilter(rates *CurrencyRates, currency, name, op, value string) (searchFilter, bool) {
+	if rates == nil || (name != "price" && name != "цена") {
+		return nil, false
+	}
+	wanted, err := ParseMoney(value)
+	if err != nil {
+		return nil, false
+	}
+	limit := float64(rates.ToBase(wanted, currency))
+	return func(device Device) bool {
+		return compareNumbers(float64(rates.ToBase(device.Price, device.Currency)), op, limit)
+	}, true
+}
+
+func sortDevicesByPrice(devices []Device, rates *CurrencyRates) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		return rates.ToBase(devices[i].Price, devices[i].Currency) < rates.ToBase(devices[j].Price, devices[j].Currency)
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestPriceFilterAcrossCurrencies(t *testing.T) {
+	rates := &CurrencyRates{Base: CurrencyRUB, Rates: map[string]float64{CurrencyRUB: 1, CurrencyUSD: 90}}
+	devices := []Device{
+		{ID: 1, Price: 2000000, Currency: CurrencyRUB}, // 20 000 ₽
+		{ID: 2, Price: 30000, Currency: CurrencyUSD},   // 300 $ = 27 000 ₽
+		{ID: 3, Price: 4000000, Currency: CurrencyRUB}, // 40 000 ₽
+		{ID: 4, Price: 50000, Currency: CurrencyUSD},   // 500 $ = 45 000 ₽
+	}
+
+	for _, c := range []struct {
+		query    string
+		currency string
+		want     []int
+	}{
+		{"цена<=30000", "", []int{1, 2}},
+		{"цена<=30000", CurrencyRUB, []int{1, 2}},
+		{"price>=300", CurrencyUSD, []int{2, 3, 4}},
+		{"price<300", CurrencyUSD, []int{1}},
+		{"цена>40000", "", []int{4}},
+		{"цена:20000", "", []int{1}},
+	} {
+		text, filters := parseSearchQuery("iphone "+c.query, rates, c.currency)
+		if text != "iphone" || len(filters) != 1 {
+			t.Fatalf("%q: текст %q, фильтров %d", c.query, text, len(filters))
+		}
+		var got []int
+		for _, device := range devices {
+			if matchesFilters(device, filters) {
+				got = append(got, device.ID)
+			}
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%q в %q: найдены %v, ожидались %v", c.query, c.currency, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%q в %q: найдены %v, ожидались %v", c.query, c.currency, got, c.want)
+				break
+			}
+		}
+	}
+
+	// Без курсов и с некорректной суммой слово остается частью текста запроса
+	if text, filters := parseSearchQuery("цена<=дешево", rates, ""); len(filters) != 0 || text != "цена<=дешево" {
+		t.Errorf("некорректная сумма: %q, %d фильтров", text, len(filters))
+	}
+	if _, filters := parseSearchQuery("цена<=100", nil, ""); len(filters) != 0 {
+		t.Error("фильтр по цене без курсов")
+	}
+}
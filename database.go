@@ -1,265 +1,676 @@
-package main
-
-import (
-	"database/sql"
-	"fmt"
-
-	_ "github.com/mattn/go-sqlite3"
-)
-
-type Database struct {
-	db *sql.DB
-}
-
-func NewDatabase(dbPath string) (*Database, error) {
-	db, err := sql.Open("sqlite3", dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось открыть базу данных: %v", err)
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("не удалось подключиться к базе данных: %v", err)
-	}
-
-	database := &Database{db: db}
-	if err := database.createTables(); err != nil {
-		return nil, fmt.Errorf("не удалось создать таблицы: %v", err)
-	}
-
-	return database, nil
-}
-
-func (d *Database) Close() error {
-	return d.db.Close()
-}
-
-func (d *Database) createTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY,
-			first_name TEXT,
-			last_name TEXT,
-			username TEXT,
-			contact TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS devices (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			description TEXT,
-			price REAL,
-			seller_id INTEGER,
-			seller_name TEXT,
-			contact TEXT,
-			category TEXT,
-			FOREIGN KEY (seller_id) REFERENCES users(id)
-		)`,
-	}
-
-	for _, query := range queries {
-		_, err := d.db.Exec(query)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func (d *Database) SaveUser(user User) error {
-	query := `INSERT OR REPLACE INTO users (id, first_name, last_name, username, contact) 
-              VALUES (?, ?, ?, ?, ?)`
-	
-	_, err := d.db.Exec(query, user.ID, user.FirstName, user.LastName, user.Username, user.Contact)
-	return err
-}
-
-func (d *Database) GetUsers() (map[int64]User, error) {
-	query := `SELECT id, first_name, last_name, username, contact FROM users`
-	
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	users := make(map[int64]User)
-	for rows.Next() {
-		var user User
-		if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Contact); err != nil {
-			return nil, err
-		}
-		users[user.ID] = user
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return users, nil
-}
-
-func (d *Database) SaveDevice(device Device) (int, error) {
-	query := `INSERT INTO devices (name, description, price, seller_id, seller_name, contact, category) 
-              VALUES (?, ?, ?, ?, ?, ?, ?)`
-	
-	result, err := d.db.Exec(query, device.Name, device.Description, device.Price, 
-		device.SellerID, device.SellerName, device.Contact, device.Category)
-	if err != nil {
-		return 0, err
-	}
-	
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-	
-	return int(id), nil
-}
-
-func (d *Database) GetDevices() ([]Device, error) {
-	query := `SELECT id, name, description, price, seller_id, seller_name, contact, category FROM devices`
-	
-	rows, err := d.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var devices []Device
-	for rows.Next() {
-		var device Device
-		if err := rows.Scan(&device.ID, &device.Name, &device.Description, &device.Price, 
-			&device.SellerID, &device.SellerName, &device.Contact, &device.Category); err != nil {
-			return nil, err
-		}
-		devices = append(devices, device)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return devices, nil
-}
-
-func (d *Database) GetDevicesByCategory(category string) ([]Device, error) {
-	query := `SELECT id, name, description, price, seller_id, seller_name, contact, category 
-              FROM devices WHERE category = ?`
-	
-	rows, err := d.db.Query(query, category)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var devices []Device
-	for rows.Next() {
-		var device Device
-		if err := rows.Scan(&device.ID, &device.Name, &device.Description, &device.Price, 
-			&device.SellerID, &device.SellerName, &device.Contact, &device.Category); err != nil {
-			return nil, err
-		}
-		devices = append(devices, device)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return devices, nil
-}
-
-func (d *Database) GetDevicesByUser(userID int64) ([]Device, error) {
-	query := `SELECT id, name, description, price, seller_id, seller_name, contact, category 
-              FROM devices WHERE seller_id = ?`
-	
-	rows, err := d.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var devices []Device
-	for rows.Next() {
-		var device Device
-		if err := rows.Scan(&device.ID, &device.Name, &device.Description, &device.Price, 
-			&device.SellerID, &device.SellerName, &device.Contact, &device.Category); err != nil {
-			return nil, err
-		}
-		devices = append(devices, device)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return devices, nil
-}
-
-func (d *Database) GetDeviceByID(deviceID int) (Device, bool, error) {
-	query := `SELECT id, name, description, price, seller_id, seller_name, contact, category 
-              FROM devices WHERE id = ?`
-	
-	var device Device
-	err := d.db.QueryRow(query, deviceID).Scan(&device.ID, &device.Name, &device.Description, 
-		&device.Price, &device.SellerID, &device.SellerName, &device.Contact, &device.Category)
-	
-	if err == sql.ErrNoRows {
-		return Device{}, false, nil
-	} else if err != nil {
-		return Device{}, false, err
-	}
-	
-	return device, true, nil
-}
-
-func (d *Database) RemoveDevice(deviceID int) error {
-	query := `DELETE FROM devices WHERE id = ?`
-	
-	_, err := d.db.Exec(query, deviceID)
-	return err
-}
-
-func (d *Database) SearchDevices(query string) ([]Device, error) {
-	searchQuery := `SELECT id, name, description, price, seller_id, seller_name, contact, category 
-                   FROM devices WHERE name LIKE ? OR description LIKE ?`
-	
-	searchPattern := "%" + query + "%"
-	rows, err := d.db.Query(searchQuery, searchPattern, searchPattern)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var devices []Device
-	for rows.Next() {
-		var device Device
-		if err := rows.Scan(&device.ID, &device.Name, &device.Description, &device.Price, 
-			&device.SellerID, &device.SellerName, &device.Contact, &device.Category); err != nil {
-			return nil, err
-		}
-		devices = append(devices, device)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return devices, nil
-}
-
-func (d *Database) GetNextDeviceID() (int, error) {
-	query := `SELECT MAX(id) FROM devices`
-	
-	var maxID sql.NullInt64
-	err := d.db.QueryRow(query).Scan(&maxID)
-	if err != nil {
-		return 0, err
-	}
-	
-	if maxID.Valid {
-		return int(maxID.Int64) + 1, nil
-	}
-	
-	return 1, nil
-} 
\ No newline at end of file
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Database struct {
+	db      *sql.DB
+	dialect dialect
+
+	// Подготовленные запросы по тексту запроса; nil, если кэш отключен в конфигурации
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+}
+
+const deviceColumns = `id, name, description, price_minor, currency, seller_id, seller_name, contact, category, status, moderation_note, created_at,
+	condition_grade, defects, kit, warranty_until, imei, imei_status, city, latitude, longitude, delivery, shipping_cost_minor,
+	contact_verified`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDevice(row rowScanner) (Device, error) {
+	var device Device
+	var createdAt, warrantyUntil sql.NullTime
+	var defects, kit, delivery string
+	var latitude, longitude sql.NullFloat64
+	err := row.Scan(&device.ID, &device.Name, &device.Description, &device.Price, &device.Currency,
+		&device.SellerID, &device.SellerName, &device.Contact, &device.Category,
+		&device.Status, &device.ModerationNote, &createdAt,
+		&device.Condition, &defects, &kit, &warrantyUntil, &device.IMEI, &device.IMEIStatus,
+		&device.City, &latitude, &longitude, &delivery, &device.ShippingCost,
+		&device.ContactVerified)
+	device.CreatedAt = createdAt.Time
+	device.Defects = splitCodes(defects)
+	device.Kit = splitCodes(kit)
+	device.Delivery = splitCodes(delivery)
+	device.WarrantyUntil = warrantyUntil.Time
+	device.Location = GeoPoint{Latitude: latitude.Float64, Longitude: longitude.Float64}
+	return device, err
+}
+
+// NewDatabase подключается к SQLite или PostgreSQL в зависимости от строки подключения
+// и приводит схему к актуальной версии
+func NewDatabase(config DatabaseConfig) (*Database, error) {
+	database, err := openDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.migrate(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("не удалось обновить схему базы данных: %v", err)
+	}
+
+	return database, nil
+}
+
+// openDatabase подключается к базе без применения миграций
+func openDatabase(config DatabaseConfig) (*Database, error) {
+	dialect := dialectForDSN(config.DSN)
+	db, err := sql.Open(dialect.driver, dialect.connectionString(config))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных: %v", err)
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetimeSeconds) * time.Second)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось подключиться к базе данных: %v", err)
+	}
+
+	database := &Database{db: db, dialect: dialect}
+	if config.PreparedStatements {
+		database.stmts = make(map[string]*sql.Stmt)
+	}
+	return database, nil
+}
+
+func (d *Database) Close() error {
+	d.stmtMu.Lock()
+	for _, stmt := range d.stmts {
+		stmt.Close()
+	}
+	d.stmts = nil
+	d.stmtMu.Unlock()
+
+	return d.db.Close()
+}
+
+// prepared возвращает подготовленный запрос из кэша, готовя его при первом обращении.
+// Через кэш идут только запросы-константы, поэтому он ограничен их числом; запросы
+// с переменным числом параметров выполняются напрямую через d.db.
+func (d *Database) prepared(query string) (*sql.Stmt, bool) {
+	d.stmtMu.Lock()
+	defer d.stmtMu.Unlock()
+
+	if d.stmts == nil {
+		return nil, false
+	}
+	if stmt, ok := d.stmts[query]; ok {
+		return stmt, true
+	}
+
+	stmt, err := d.db.Prepare(d.dialect.rebind(query))
+	if err != nil {
+		return nil, false
+	}
+	d.stmts[query] = stmt
+	return stmt, true
+}
+
+// Если запрос не удалось подготовить, он выполняется напрямую,
+// чтобы вызывающий код получил исходную ошибку СУБД
+func (d *Database) exec(query string, args ...interface{}) (sql.Result, error) {
+	if stmt, ok := d.prepared(query); ok {
+		return stmt.Exec(args...)
+	}
+	return d.db.Exec(d.dialect.rebind(query), args...)
+}
+
+func (d *Database) query(query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt, ok := d.prepared(query); ok {
+		return stmt.Query(args...)
+	}
+	return d.db.Query(d.dialect.rebind(query), args...)
+}
+
+func (d *Database) queryRow(query string, args ...interface{}) *sql.Row {
+	if stmt, ok := d.prepared(query); ok {
+		return stmt.QueryRow(args...)
+	}
+	return d.db.QueryRow(d.dialect.rebind(query), args...)
+}
+
+func (d *Database) SaveUser(user User) error {
+	query := `INSERT INTO users (id, first_name, last_name, username, contact, language, display_currency, city, latitude, longitude, phone, joined_at) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+              ON CONFLICT (id) DO UPDATE SET first_name = excluded.first_name, last_name = excluded.last_name,
+                  username = excluded.username, contact = excluded.contact, language = excluded.language,
+                  display_currency = excluded.display_currency, city = excluded.city,
+                  latitude = excluded.latitude, longitude = excluded.longitude, phone = excluded.phone,
+                  joined_at = COALESCE(users.joined_at, excluded.joined_at)`
+
+	// Дата регистрации записывается один раз, при первом сохранении профиля с ней
+	joinedAt := sql.NullTime{Time: user.JoinedAt, Valid: !user.JoinedAt.IsZero()}
+	latitude, longitude := nullableCoordinates(user.Location)
+	_, err := d.exec(query, user.ID, user.FirstName, user.LastName, user.Username, user.Contact, user.Language, user.DisplayCurrency,
+		user.City, latitude, longitude, user.Phone, joinedAt)
+	return err
+}
+
+func (d *Database) GetUsers() (map[int64]User, error) {
+	query := `SELECT id, first_name, last_name, username, contact, language, display_currency, city, latitude, longitude, phone, joined_at FROM users`
+	
+	rows, err := d.query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[int64]User)
+	for rows.Next() {
+		var user User
+		var latitude, longitude sql.NullFloat64
+		var joinedAt sql.NullTime
+		if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Contact, &user.Language, &user.DisplayCurrency,
+			&user.City, &latitude, &longitude, &user.Phone, &joinedAt); err != nil {
+			return nil, err
+		}
+		user.JoinedAt = joinedAt.Time
+		user.Location = GeoPoint{Latitude: latitude.Float64, Longitude: longitude.Float64}
+		users[user.ID] = user
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// RateSeller сохраняет оценку продавца; повторная оценка того же покупателя заменяет прежнюю
+func (d *Database) RateSeller(sellerID, raterID int64, score int, at time.Time) error {
+	query := `INSERT INTO seller_ratings (seller_id, rater_id, score, created_at) VALUES (?, ?, ?, ?)
+              ON CONFLICT (seller_id, rater_id) DO UPDATE SET score = excluded.score, created_at = excluded.created_at`
+	_, err := d.exec(query, sellerID, raterID, score, at)
+	return err
+}
+
+func (d *Database) GetSellerRating(sellerID int64) (SellerRating, error) {
+	var rating SellerRating
+	err := d.queryRow(`SELECT COUNT(*), COALESCE(AVG(score), 0) FROM seller_ratings WHERE seller_id = ?`, sellerID).
+		Scan(&rating.Count, &rating.Average)
+	return rating, err
+}
+
+func (d *Database) GetCategories() ([]Category, error) {
+	rows, err := d.query(`SELECT code, COALESCE(parent_code, ''), emoji, sort_order, enabled FROM categories ORDER BY sort_order, code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []Category
+	index := make(map[string]int)
+	for rows.Next() {
+		category := Category{Names: make(map[string]string)}
+		if err := rows.Scan(&category.Code, &category.Parent, &category.Emoji, &category.SortOrder, &category.Enabled); err != nil {
+			return nil, err
+		}
+		index[category.Code] = len(categories)
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	nameRows, err := d.query(`SELECT category_code, language, name FROM category_names`)
+	if err != nil {
+		return nil, err
+	}
+	defer nameRows.Close()
+
+	for nameRows.Next() {
+		var code, lang, name string
+		if err := nameRows.Scan(&code, &lang, &name); err != nil {
+			return nil, err
+		}
+		if i, ok := index[code]; ok {
+			categories[i].Names[lang] = name
+		}
+	}
+	if err := nameRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// SaveCategory создает или обновляет категорию вместе с названиями на всех языках
+func (d *Database) SaveCategory(category Category) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// У корневой категории parent_code равен NULL: внешний ключ не допускает пустую строку
+	var parent interface{}
+	if category.Parent != "" {
+		parent = category.Parent
+	}
+	_, err = tx.Exec(d.dialect.rebind(`INSERT INTO categories (code, parent_code, emoji, sort_order, enabled) VALUES (?, ?, ?, ?, ?)
+              ON CONFLICT (code) DO UPDATE SET parent_code = excluded.parent_code, emoji = excluded.emoji,
+                  sort_order = excluded.sort_order, enabled = excluded.enabled`),
+		category.Code, parent, category.Emoji, category.SortOrder, category.Enabled)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(d.dialect.rebind(`DELETE FROM category_names WHERE category_code = ?`), category.Code); err != nil {
+		return err
+	}
+	for lang, name := range category.Names {
+		_, err := tx.Exec(d.dialect.rebind(`INSERT INTO category_names (category_code, language, name) VALUES (?, ?, ?)`),
+			category.Code, lang, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const insertDeviceQuery = `INSERT INTO devices (name, description, price_minor, currency, seller_id, seller_name, contact, category, status, moderation_note, created_at,
+              condition_grade, defects, kit, warranty_until, imei, imei_status, city, latitude, longitude,
+              delivery, shipping_cost_minor, contact_verified) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+
+// nullableCoordinates сохраняет отсутствие координат как NULL, а не как точку (0, 0)
+func nullableCoordinates(point GeoPoint) (sql.NullFloat64, sql.NullFloat64) {
+	valid := !point.IsZero()
+	return sql.NullFloat64{Float64: point.Latitude, Valid: valid}, sql.NullFloat64{Float64: point.Longitude, Valid: valid}
+}
+
+func insertDeviceArgs(device Device) []interface{} {
+	warrantyUntil := sql.NullTime{Time: device.WarrantyUntil, Valid: !device.WarrantyUntil.IsZero()}
+	latitude, longitude := nullableCoordinates(device.Location)
+	return []interface{}{device.Name, device.Description, device.Price, device.Currency,
+		device.SellerID, device.SellerName, device.Contact, device.Category, device.Status, device.ModerationNote, device.CreatedAt,
+		device.Condition, strings.Join(device.Defects, ","), strings.Join(device.Kit, ","), warrantyUntil, device.IMEI, device.IMEIStatus,
+		device.City, latitude, longitude, strings.Join(device.Delivery, ","), device.ShippingCost,
+		device.ContactVerified}
+}
+
+// SaveDevice сохраняет объявление вместе с характеристиками
+func (d *Database) SaveDevice(device Device) (int, error) {
+	ids, err := d.SaveDevices([]Device{device})
+	if err != nil {
+		return 0, err
+	}
+	
+	return ids[0], nil
+}
+
+// SaveDevices сохраняет объявления в одной транзакции: либо все, либо ни одного
+func (d *Database) SaveDevices(devices []Device) ([]int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(d.dialect.rebind(insertDeviceQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(devices))
+	for _, device := range devices {
+		var id int
+		if err := stmt.QueryRow(insertDeviceArgs(device)...).Scan(&id); err != nil {
+			return nil, err
+		}
+		if err := d.insertAttributes(tx, id, device.Attributes); err != nil {
+			return nil, err
+		}
+		if err := d.recordPosting(tx, device); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Записи журнала размещений старше недели для суточного лимита уже не нужны
+const listingPostingsRetention = 7 * 24 * time.Hour
+
+// recordPosting отмечает размещение в журнале, который не меняется при удалении
+// объявления, и заодно удаляет устаревшие записи продавца
+func (d *Database) recordPosting(tx *sql.Tx, device Device) error {
+	postedAt := device.CreatedAt
+	if postedAt.IsZero() {
+		postedAt = time.Now()
+	}
+	if _, err := tx.Exec(d.dialect.rebind(`DELETE FROM listing_postings WHERE seller_id = ? AND posted_at < ?`),
+		device.SellerID, postedAt.Add(-listingPostingsRetention).Unix()); err != nil {
+		return err
+	}
+	_, err := tx.Exec(d.dialect.rebind(`INSERT INTO listing_postings (seller_id, posted_at) VALUES (?, ?)`),
+		device.SellerID, postedAt.Unix())
+	return err
+}
+
+// CountPostingsSince возвращает, сколько объявлений продавец разместил начиная с since,
+// включая удаленные
+func (d *Database) CountPostingsSince(sellerID int64, since time.Time) (int, error) {
+	var count int
+	err := d.queryRow(`SELECT COUNT(*) FROM listing_postings WHERE seller_id = ? AND posted_at >= ?`, sellerID, since.Unix()).Scan(&count)
+	return count, err
+}
+
+func (d *Database) insertAttributes(tx *sql.Tx, deviceID int, attributes map[string]string) error {
+	for name, value := range attributes {
+		_, err := tx.Exec(d.dialect.rebind(`INSERT INTO device_attributes (device_id, name, value) VALUES (?, ?, ?)`),
+			deviceID, name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SQLite ограничивает число параметров запроса, поэтому характеристики
+// загружаются частями
+const attributesBatchSize = 500
+
+// loadAttributes дополняет объявления характеристиками из device_attributes
+func (d *Database) loadAttributes(devices []Device) error {
+	index := make(map[int]int, len(devices))
+	for i := range devices {
+		index[devices[i].ID] = i
+	}
+
+	for start := 0; start < len(devices); start += attributesBatchSize {
+		end := min(start+attributesBatchSize, len(devices))
+		args := make([]interface{}, 0, end-start)
+		for _, device := range devices[start:end] {
+			args = append(args, device.ID)
+		}
+
+		// Запрос не кэшируется: число параметров меняется от выборки к выборке
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+		rows, err := d.db.Query(d.dialect.rebind(`SELECT device_id, name, value FROM device_attributes WHERE device_id IN (`+placeholders+`)`), args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var deviceID int
+			var name, value string
+			if err := rows.Scan(&deviceID, &name, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			device := &devices[index[deviceID]]
+			if device.Attributes == nil {
+				device.Attributes = make(map[string]string)
+			}
+			device.Attributes[name] = value
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Database) GetDevices() ([]Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE status = 'active'`
+	
+	rows, err := d.query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, d.loadAttributes(devices)
+}
+
+// GetAllDevices возвращает объявления во всех статусах, например для экспорта
+func (d *Database) GetAllDevices() ([]Device, error) {
+	rows, err := d.query(`SELECT ` + deviceColumns + ` FROM devices ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices, err := scanDevices(rows)
+	if err != nil {
+		return nil, err
+	}
+	return devices, d.loadAttributes(devices)
+}
+
+func scanDevices(rows *sql.Rows) ([]Device, error) {
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+// GetDevicesByCategory возвращает активные объявления из любой из категорий: узел
+// каталога передается вместе со всеми подкатегориями
+func (d *Database) GetDevicesByCategory(categories ...string) ([]Device, error) {
+	// Пустой список IN () в PostgreSQL — синтаксическая ошибка
+	if len(categories) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(categories)), ", ")
+	query := `SELECT ` + deviceColumns + ` 
+              FROM devices WHERE category IN (` + placeholders + `) AND status = 'active'`
+
+	args := make([]interface{}, len(categories))
+	for i, category := range categories {
+		args[i] = category
+	}
+	// Запрос не кэшируется: число параметров зависит от размера поддерева категорий
+	rows, err := d.db.Query(d.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, d.loadAttributes(devices)
+}
+
+func (d *Database) GetDevicesByUser(userID int64) ([]Device, error) {
+	query := `SELECT ` + deviceColumns + ` 
+              FROM devices WHERE seller_id = ?`
+	
+	rows, err := d.query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, d.loadAttributes(devices)
+}
+
+func (d *Database) GetDeviceByID(deviceID int) (Device, bool, error) {
+	query := `SELECT ` + deviceColumns + ` 
+              FROM devices WHERE id = ?`
+	
+	device, err := scanDevice(d.queryRow(query, deviceID))
+	
+	if err == sql.ErrNoRows {
+		return Device{}, false, nil
+	} else if err != nil {
+		return Device{}, false, err
+	}
+	
+	devices := []Device{device}
+	if err := d.loadAttributes(devices); err != nil {
+		return Device{}, false, err
+	}
+	return devices[0], true, nil
+}
+
+// FindDevicesByIMEI ищет объявления с тем же IMEI среди всех, кроме отклоненных:
+// телефон на модерации или уже проданный не должен повторно получить отметку проверки
+func (d *Database) FindDevicesByIMEI(imei string) ([]Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE imei = ? AND status <> 'rejected'`
+
+	rows, err := d.query(query, imei)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+func (d *Database) GetDevicesByStatus(status string) ([]Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE status = ?`
+
+	rows, err := d.query(query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, d.loadAttributes(devices)
+}
+
+// UpdateDeviceStatus меняет статус, только если объявление все еще в статусе from:
+// так два модератора, нажавшие кнопки одновременно, не решают судьбу объявления дважды
+func (d *Database) UpdateDeviceStatus(deviceID int, from, status, note string) (bool, error) {
+	query := `UPDATE devices SET status = ?, moderation_note = ? WHERE id = ? AND status = ?`
+
+	result, err := d.exec(query, status, note, deviceID, from)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (d *Database) RemoveDevice(deviceID int) error {
+	// Характеристики удаляются явно: каскадное удаление работает, только если
+	// в конфигурации SQLite включены внешние ключи
+	if _, err := d.exec(`DELETE FROM device_attributes WHERE device_id = ?`, deviceID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM devices WHERE id = ?`
+	
+	_, err := d.exec(query, deviceID)
+	return err
+}
+
+func (d *Database) SearchDevices(query string) ([]Device, error) {
+	searchQuery := `SELECT ` + deviceColumns + ` 
+                   FROM devices WHERE (name ` + d.dialect.likeOperator + ` ? OR description ` + d.dialect.likeOperator + ` ?) AND status = 'active'`
+	
+	searchPattern := "%" + query + "%"
+	rows, err := d.query(searchQuery, searchPattern, searchPattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, d.loadAttributes(devices)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Telegram принимает в start не больше 64 символов из A-Z, a-z, 0-9, _ и -, поэтому
+// поисковый запрос кодируется в base64 без паддинга, а длинные запросы не делятся.
+// Запрос из латиницы и кириллицы сначала переводится в однобайтовую кодировку
+// (префикс q_) и помещается в ссылку целиком до 46 символов; для прочих символов
+// остается base64 от UTF-8 (префикс search_), где кириллицы влезает только 21 символ.
+const (
+	deepLinkMaxPayload    = 64
+	deepLinkListingPrefix = "listing_"
+	deepLinkSearchPrefix  = "search_"
+	deepLinkQueryPrefix   = "q_"
+	deepLinkSellerPrefix  = "seller_"
+)
+
+// Коды однобайтовой кодировки запроса: ASCII как есть, затем строчные а-я, прописные
+// А-Я, ё и Ё
+const (
+	compactLower = 0x80
+	compactUpper = 0xA0
+	compactYo    = 0xC0
+	compactYoCap = 0xC1
+)
+
+// deepLink возвращает ссылку t.me, которая откроет бота с командой /start <payload>
+func deepLink(botName, payload string) string {
+	return "https://t.me/" + botName + "?start=" + payload
+}
+
+// shareLink открывает в Telegram выбор чата, куда отправить ссылку с подписью
+func shareLink(link, text string) string {
+	return "https://t.me/share/url?url=" + url.QueryEscape(link) + "&text=" + url.QueryEscape(text)
+}
+
+func listingPayload(deviceID int) string {
+	return deepLinkListingPrefix + strconv.Itoa(deviceID)
+}
+
+// searchPayload кодирует поисковый запрос вместе с фильтрами; false — запрос
+// слишком длинный для ссылки
+func searchPayload(query string) (string, bool) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", false
+	}
+	var payload string
+	if compact, ok := encodeCompactQuery(query); ok {
+		payload = deepLinkQueryPrefix + base64.RawURLEncoding.EncodeToString(compact)
+	} else {
+		payload = deepLinkSearchPrefix + base64.RawURLEncoding.EncodeToString([]byte(query))
+	}
+	return payload, len(payload) <= deepLinkMaxPayload
+}
+
+// parseSearchPayload разбирает payload целиком, с префиксом q_ или search_; ссылки
+// search_ из прежних версий продолжают работать
+func parseSearchPayload(payload string) (string, bool) {
+	var query string
+	if encoded, ok := strings.CutPrefix(payload, deepLinkQueryPrefix); ok {
+		data, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", false
+		}
+		if query, ok = decodeCompactQuery(data); !ok {
+			return "", false
+		}
+	} else if encoded, ok := strings.CutPrefix(payload, deepLinkSearchPrefix); ok {
+		data, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", false
+		}
+		query = string(data)
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", false
+	}
+	return query, true
+}
+
+// encodeCompactQuery переводит запрос в однобайтовую кодировку; false — в запросе
+// есть символы вне ASCII и русского алфавита
+func encodeCompactQuery(query string) ([]byte, bool) {
+	data := make([]byte, 0, len(query))
+	for _, r := range query {
+		switch {
+		case r < 0x80:
+			data = append(data, byte(r))
+		case r >= 'а' && r <= 'я':
+			data = append(data, byte(compactLower+r-'а'))
+		case r >= 'А' && r <= 'Я':
+			data = append(data, byte(compactUpper+r-'А'))
+		case r == 'ё':
+			data = append(data, compactYo)
+		case r == 'Ё':
+			data = append(data, compactYoCap)
+		default:
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+func decodeCompactQuery(data []byte) (string, bool) {
+	var builder strings.Builder
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			builder.WriteByte(b)
+		case b < compactUpper:
+			builder.WriteRune('а' + rune(b-compactLower))
+		case b < compactYo:
+			builder.WriteRune('А' + rune(b-compactUpper))
+		case b == compactYo:
+			builder.WriteRune('ё')
+		case b == compactYoCap:
+			builder.WriteRune('Ё')
+		default:
+			return "", false
+		}
+	}
+	return builder.String(), true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSearchPayload(t *testing.T) {
+	allowed := regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	for _, query := range []string{
+		"iphone память:128 акб>=85",
+		"samsung",
+		"pixel город:москва",
+		"Ёлка ёж ЯБЛОКО",
+		"смартфон самсунг память:256 цена<=30000", // 40 символов, в base64 от UTF-8 не влезает
+		"iphone 📱",
+	} {
+		payload, ok := searchPayload(query)
+		if !ok || len(payload) > deepLinkMaxPayload || !allowed.MatchString(payload) {
+			t.Fatalf("searchPayload(%q) = %q, %v", query, payload, ok)
+		}
+		decoded, ok := parseSearchPayload(payload)
+		if !ok || decoded != query {
+			t.Errorf("parseSearchPayload(%q) = %q, %v", payload, decoded, ok)
+		}
+	}
+	if payload, _ := searchPayload("iphone 📱"); !strings.HasPrefix(payload, deepLinkSearchPrefix) {
+		t.Errorf("запрос с эмодзи: %q", payload)
+	}
+	if _, ok := searchPayload(strings.Repeat("очень длинный запрос ", 3)); ok {
+		t.Error("длинный запрос не помещается в ссылку")
+	}
+	// Ссылка прежнего формата
+	if query, ok := parseSearchPayload("search_0L_QuNC60YHQtdC70Yw"); !ok || query != "пиксель" {
+		t.Errorf("старая ссылка: %q, %v", query, ok)
+	}
+	if _, ok := parseSearchPayload("q_!!!"); ok {
+		t.Error("испорченный payload разобран")
+	}
+
+	if got := deepLink("market_bot", listingPayload(123)); got != "https://t.me/market_bot?start=listing_123" {
+		t.Errorf("deepLink = %q", got)
+	}
+}
+
+func TestShareButtons(t *testing.T) {
+	device := Device{ID: 7, SellerID: 1, Name: "iPhone 13"}
+	keyboard := getDeviceCardKeyboard(LangRU, "market_bot", device)
+	row := keyboard.InlineKeyboard[0]
+	if len(row) != 2 || row[1].URL == nil || !strings.Contains(*row[1].URL, "start%3Dlisting_7") {
+		t.Fatalf("кнопки карточки: %+v", row)
+	}
+	if row := getDeviceCardKeyboard(LangRU, "", device).InlineKeyboard[0]; len(row) != 1 {
+		t.Errorf("без имени бота кнопки «Поделиться» быть не должно: %+v", row)
+	}
+
+	main := len(getMainKeyboard(LangRU).InlineKeyboard)
+	if got := len(getSearchKeyboard(LangRU, "market_bot", "iphone").InlineKeyboard); got != main+1 {
+		t.Errorf("строк в клавиатуре поиска: %d", got)
+	}
+	if got := len(getSearchKeyboard(LangRU, "market_bot", strings.Repeat("iphone ", 20)).InlineKeyboard); got != main {
+		t.Errorf("длинный запрос не должен давать ссылку: %d строк", got)
+	}
+}
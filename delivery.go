@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Способы получения устройства. Названия берутся из каталога сообщений
+// (delivery.<код>) и короткие, чтобы годиться и для фильтра доставка:почта.
+const (
+	DeliveryPickup   = "pickup"
+	DeliveryMeetup   = "meetup"
+	DeliveryCourier  = "courier"
+	DeliveryShipping = "shipping"
+)
+
+var deliveryMethods = []string{DeliveryPickup, DeliveryMeetup, DeliveryCourier, DeliveryShipping}
+
+func normalizeDelivery(input string) ([]string, bool) {
+	return normalizeChecklist("delivery.", deliveryMethods, input)
+}
+
+// parseShippingCost разбирает стоимость отправки; «бесплатно» и «0» — бесплатная доставка
+func parseShippingCost(input string) (Money, bool) {
+	input = strings.TrimSpace(input)
+	switch strings.ToLower(input) {
+	case "", "бесплатно", "free":
+		return 0, true
+	}
+	cost, err := ParseMoney(input)
+	if err != nil || cost < 0 {
+		return 0, false
+	}
+	return cost, true
+}
+
+// formatDelivery перечисляет способы получения; у отправки почтой в скобках стоимость
+func formatDelivery(lang string, device Device) string {
+	names := make([]string, len(device.Delivery))
+	for i, method := range device.Delivery {
+		names[i] = T(lang, "delivery."+method)
+		if method != DeliveryShipping {
+			continue
+		}
+		cost := T(lang, "delivery.free")
+		if device.ShippingCost > 0 {
+			cost = formatPrice(device.ShippingCost, device.Currency)
+		}
+		names[i] += " (" + cost + ")"
+	}
+	return strings.Join(names, ", ")
+}
+
+// deliveryFilter — фильтр поиска доставка:почта или delivery:courier. Объявления без
+// указанных способов получения под фильтр не попадают.
+func deliveryFilter(name, op, value string) (searchFilter, bool) {
+	if op != "=" || (name != "delivery" && name != "доставка") {
+		return nil, false
+	}
+	method, ok := normalizeChoice("delivery.", deliveryMethods, value)
+	if !ok {
+		return nil, false
+	}
+	return func(device Device) bool { return containsString(device.Delivery, method) }, true
+}
+
+func getShippingCostKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.free_shipping"), "shipping_free"),
+		),
+	)
+}
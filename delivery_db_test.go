@@ -0,0 +1,32 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceDeliveryRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	id, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", Status: DeviceStatusActive, Delivery: []string{DeliveryMeetup, DeliveryShipping}, ShippingCost: 50000})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+	device, _, err := db.GetDeviceByID(id)
+	if err != nil || len(device.Delivery) != 2 || device.Delivery[1] != DeliveryShipping || device.ShippingCost != 50000 {
+		t.Fatalf("способы получения после чтения: %+v, %v", device, err)
+	}
+	if got := formatDelivery(LangRU, device); got != "Встреча, Почта (500.00 ₽)" {
+		t.Errorf("formatDelivery = %q", got)
+	}
+	device.ShippingCost = 0
+	if got := formatDelivery(LangEN, device); got != "Meetup, Shipping (free)" {
+		t.Errorf("formatDelivery = %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestDeliveryFilterAndImport(t *testing.T) {
+	shipped := Device{Delivery: []string{DeliveryPickup, DeliveryShipping}}
+	local := Device{Delivery: []string{DeliveryMeetup}}
+	for query, want := range map[string][2]bool{
+		"доставка:почта":  {true, false},
+		"delivery:meetup": {false, true},
+		"доставка:курьер": {false, false},
+		"delivery:pickup": {true, false},
+	} {
+		_, filters := parseSearchQuery(query, nil, "")
+		if len(filters) != 1 || matchesFilters(shipped, filters) != want[0] || matchesFilters(local, filters) != want[1] {
+			t.Errorf("%q: фильтров %d", query, len(filters))
+		}
+	}
+
+	var device Device
+	if key, _ := parseExchangeDelivery(exchangeDevice{Delivery: "Почта; meetup", ShippingCost: "350"}, &device); key != "" {
+		t.Fatalf("parseExchangeDelivery: %s", key)
+	}
+	if len(device.Delivery) != 2 || device.Delivery[0] != DeliveryMeetup || device.ShippingCost != 35000 {
+		t.Fatalf("разобрано: %+v", device)
+	}
+	if key, _ := parseExchangeDelivery(exchangeDevice{Delivery: "pickup", ShippingCost: "350"}, &device); key != "import.error.bad_shipping" {
+		t.Errorf("стоимость без отправки: %q", key)
+	}
+	if key, _ := parseExchangeDelivery(exchangeDevice{Delivery: "телепорт"}, &device); key != "import.error.bad_delivery" {
+		t.Errorf("неизвестный способ: %q", key)
+	}
+}
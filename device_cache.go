@@ -0,0 +1,117 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// deviceCache — кэш результатов частых запросов каталога (все объявления, категория).
+// Записи живут не дольше ttl, поэтому изменения, сделанные другими экземплярами бота,
+// становятся видны не позже чем через ttl; собственные изменения сбрасывают кэш сразу.
+// Списки копируются при записи и чтении, чтобы вызывающий код не мог испортить кэш.
+type deviceCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]deviceCacheEntry
+	// Порядковый номер записи: при переполнении вытесняется самая старая
+	seq uint64
+}
+
+type deviceCacheEntry struct {
+	devices []Device
+	expires time.Time
+	seq     uint64
+}
+
+func newDeviceCache(ttl time.Duration, maxEntries int) *deviceCache {
+	return &deviceCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]deviceCacheEntry),
+	}
+}
+
+func (c *deviceCache) enabled() bool {
+	return c.ttl > 0 && c.maxEntries > 0
+}
+
+func (c *deviceCache) get(key string) ([]Device, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyDevices(entry.devices), true
+}
+
+func (c *deviceCache) put(key string, devices []Device) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.seq++
+	c.entries[key] = deviceCacheEntry{devices: copyDevices(devices), expires: now.Add(c.ttl), seq: c.seq}
+}
+
+// evictLocked удаляет просроченные записи, а если таких нет — самую старую
+func (c *deviceCache) evictLocked(now time.Time) {
+	oldestKey := ""
+	var oldest uint64
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.seq < oldest {
+			oldestKey, oldest = key, entry.seq
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *deviceCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]deviceCacheEntry)
+}
+
+// copyDevices копирует и вложенные карты и срезы: иначе вызывающий код, изменив
+// характеристики или дефекты полученного объявления, изменил бы и запись в кэше
+func copyDevices(devices []Device) []Device {
+	if devices == nil {
+		return nil
+	}
+	copied := append([]Device(nil), devices...)
+	for i := range copied {
+		copied[i].Attributes = maps.Clone(copied[i].Attributes)
+		copied[i].Defects = slices.Clone(copied[i].Defects)
+		copied[i].Kit = slices.Clone(copied[i].Kit)
+		copied[i].Delivery = slices.Clone(copied[i].Delivery)
+	}
+	return copied
+}
@@ -0,0 +1,77 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeviceCacheCopiesOnReturn(t *testing.T) {
+	cache := newDeviceCache(time.Minute, 4)
+	devices := []Device{
+		{ID: 1, Name: "iPhone", Attributes: map[string]string{"storage_gb": "128"}, Defects: []string{"scratches"}},
+		{ID: 2, Name: "Pixel"},
+	}
+	cache.put("all", devices)
+	devices[0].Name = "изменено после put"
+	devices[0].Attributes["storage_gb"] = "256"
+
+	cached, ok := cache.get("all")
+	if !ok || cached[0].Name != "iPhone" {
+		t.Fatalf("get = %+v, %v", cached, ok)
+	}
+	cached[1].Name = "изменено после get"
+	cached[0].Attributes["storage_gb"] = "512"
+	cached[0].Defects[0] = "water_damage"
+
+	again, _ := cache.get("all")
+	if again[1].Name != "Pixel" {
+		t.Fatalf("кэш изменился через возвращенный срез: %+v", again)
+	}
+	if again[0].Attributes["storage_gb"] != "128" || again[0].Defects[0] != "scratches" {
+		t.Fatalf("кэш изменился через характеристики или дефекты: %+v", again[0])
+	}
+}
+
+func TestDeviceCacheExpiryAndInvalidation(t *testing.T) {
+	cache := newDeviceCache(20*time.Millisecond, 4)
+	cache.put("all", []Device{{ID: 1}})
+
+	cache.invalidate()
+	if _, ok := cache.get("all"); ok {
+		t.Fatal("запись осталась после invalidate")
+	}
+
+	cache.put("all", []Device{{ID: 1}})
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := cache.get("all"); ok {
+		t.Fatal("запись не истекла")
+	}
+}
+
+func TestDeviceCacheIsBounded(t *testing.T) {
+	cache := newDeviceCache(time.Minute, 2)
+	cache.put("category:smartphone", nil)
+	cache.put("category:tablet", nil)
+	cache.put("all", nil)
+
+	if len(cache.entries) != 2 {
+		t.Fatalf("в кэше %d записей, ожидалось 2", len(cache.entries))
+	}
+	if _, ok := cache.get("category:smartphone"); ok {
+		t.Fatal("не вытеснена самая старая запись")
+	}
+	if _, ok := cache.get("all"); !ok {
+		t.Fatal("нет последней записи")
+	}
+}
+
+func TestDeviceCacheDisabled(t *testing.T) {
+	cache := newDeviceCache(0, 8)
+	cache.put("all", []Device{{ID: 1}})
+	if _, ok := cache.get("all"); ok {
+		t.Fatal("отключенный кэш вернул запись")
+	}
+}
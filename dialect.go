@@ -0,0 +1,121 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// dialect описывает различия между поддерживаемыми СУБД. Запросы в database.go
+// пишутся с параметрами «?» и приводятся к нужному виду через rebind.
+type dialect struct {
+	name          string
+	driver        string
+	migrationsDir string
+	// Postgres нумерует параметры запроса ($1, $2), SQLite принимает «?»
+	numberedParams bool
+	// Оператор поиска без учета регистра
+	likeOperator   string
+	hasTableQuery  string
+	hasColumnQuery string
+	// Блокировка, не дающая нескольким экземплярам бота применять миграции одновременно
+	migrationLock string
+	// Дополняет строку подключения настройками из конфигурации
+	connectionString func(config DatabaseConfig) string
+	// Колонки, по которым распознаются базы, созданные до появления schema_version
+	legacyMarkers map[int][2]string
+}
+
+var sqliteDialect = dialect{
+	name:           "sqlite",
+	driver:         "sqlite3",
+	migrationsDir:  "migrations/sqlite",
+	likeOperator:   "LIKE",
+	hasTableQuery:  `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+	hasColumnQuery: `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`,
+	legacyMarkers: map[int][2]string{
+		1: {"devices", "id"},
+		2: {"devices", "status"},
+		3: {"devices", "created_at"},
+		4: {"users", "language"},
+		5: {"devices", "currency"},
+		6: {"devices", "price_minor"},
+	},
+	connectionString: sqliteConnectionString,
+}
+
+var postgresDialect = dialect{
+	name:           "postgres",
+	driver:         "postgres",
+	migrationsDir:  "migrations/postgres",
+	numberedParams: true,
+	likeOperator:   "ILIKE",
+	hasTableQuery: `SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = ?`,
+	hasColumnQuery: `SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+	migrationLock:    `SELECT pg_advisory_xact_lock(7412)`,
+	connectionString: func(config DatabaseConfig) string { return config.DSN },
+}
+
+// dialectForDSN выбирает СУБД по строке подключения: URL postgres:// или
+// key=value-строка libpq означают PostgreSQL, все остальное — путь к файлу SQLite
+func dialectForDSN(dsn string) dialect {
+	lower := strings.ToLower(strings.TrimSpace(dsn))
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") ||
+		strings.Contains(lower, "dbname=") || strings.Contains(lower, "host=") {
+		return postgresDialect
+	}
+	return sqliteDialect
+}
+
+// sqliteConnectionString передает PRAGMA через параметры go-sqlite3: так они
+// применяются к каждому соединению пула, а не только к первому
+func sqliteConnectionString(config DatabaseConfig) string {
+	params := url.Values{}
+	if config.JournalMode != "" {
+		params.Set("_journal_mode", config.JournalMode)
+	}
+	if config.Synchronous != "" {
+		params.Set("_synchronous", config.Synchronous)
+	}
+	if config.BusyTimeoutMs > 0 {
+		params.Set("_busy_timeout", strconv.Itoa(config.BusyTimeoutMs))
+	}
+	params.Set("_foreign_keys", fmt.Sprint(config.ForeignKeys))
+
+	separator := "?"
+	if strings.Contains(config.DSN, "?") {
+		separator = "&"
+	}
+	return config.DSN + separator + params.Encode()
+}
+
+func (dl dialect) rebind(query string) string {
+	if !dl.numberedParams {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Формат обмена данными между окружениями: JSON-файл со списками users и devices
+// или CSV-файлы с теми же колонками (пользователи и объявления отдельно). Цена
+// записывается строкой в основных единицах («1500.00»), время — в RFC 3339.
+type exchangeUser struct {
+	ID              int64  `json:"id"`
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	Username        string `json:"username"`
+	Contact         string `json:"contact"`
+	Language        string `json:"language"`
+	DisplayCurrency string `json:"display_currency"`
+	City            string `json:"city,omitempty"`
+	Phone           string `json:"phone,omitempty"`
+}
+
+type exchangeDevice struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Price          string `json:"price"`
+	Currency       string `json:"currency"`
+	SellerID       int64  `json:"seller_id"`
+	SellerName     string `json:"seller_name"`
+	Contact        string `json:"contact"`
+	Category       string `json:"category"`
+	Status         string `json:"status"`
+	ModerationNote string `json:"moderation_note"`
+	CreatedAt      string `json:"created_at"`
+	// В CSV — одна колонка вида storage_gb=128;color=black
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Дефекты и комплект — коды через запятую, гарантия — дата ГГГГ-ММ-ДД
+	Condition     string `json:"condition,omitempty"`
+	Defects       string `json:"defects,omitempty"`
+	Kit           string `json:"kit,omitempty"`
+	WarrantyUntil string `json:"warranty_until,omitempty"`
+	IMEI          string `json:"imei,omitempty"`
+	IMEIStatus    string `json:"imei_status,omitempty"`
+	// Геопозиция — «широта,долгота» с точкой в качестве десятичного разделителя
+	City     string `json:"city,omitempty"`
+	Location string `json:"location,omitempty"`
+	// Способы получения — коды через запятую, стоимость отправки — в валюте объявления
+	Delivery        string `json:"delivery,omitempty"`
+	ShippingCost    string `json:"shipping_cost,omitempty"`
+	ContactVerified bool   `json:"contact_verified,omitempty"`
+}
+
+type exchangeData struct {
+	Users   []exchangeUser   `json:"users"`
+	Devices []exchangeDevice `json:"devices"`
+
+	// Строки CSV, которые не удалось разобрать; попадают в отчет об импорте
+	parseErrors []importError
+}
+
+var (
+	userCSVColumns   = []string{"id", "first_name", "last_name", "username", "contact", "language", "display_currency", "city", "phone"}
+	deviceCSVColumns = []string{"id", "name", "description", "price", "currency", "seller_id", "seller_name",
+		"contact", "category", "status", "moderation_note", "created_at", "attributes",
+		"condition", "defects", "kit", "warranty_until", "imei", "imei_status",
+		"city", "location", "delivery", "shipping_cost", "contact_verified"}
+)
+
+func newExchangeData(users map[int64]User, devices []Device) exchangeData {
+	var data exchangeData
+	for _, user := range users {
+		data.Users = append(data.Users, exchangeUser{
+			ID:              user.ID,
+			FirstName:       user.FirstName,
+			LastName:        user.LastName,
+			Username:        user.Username,
+			Contact:         user.Contact,
+			Language:        user.Language,
+			DisplayCurrency: user.DisplayCurrency,
+			City:            user.City,
+			Phone:           user.Phone,
+		})
+	}
+	sort.Slice(data.Users, func(i, j int) bool { return data.Users[i].ID < data.Users[j].ID })
+
+	for _, device := range devices {
+		var createdAt, warrantyUntil, location, shippingCost string
+		if !device.CreatedAt.IsZero() {
+			createdAt = device.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		if !device.WarrantyUntil.IsZero() {
+			warrantyUntil = device.WarrantyUntil.Format("2006-01-02")
+		}
+		if !device.Location.IsZero() {
+			location = formatGeoPoint(device.Location)
+		}
+		if containsString(device.Delivery, DeliveryShipping) {
+			shippingCost = device.ShippingCost.String()
+		}
+		data.Devices = append(data.Devices, exchangeDevice{
+			ID:              device.ID,
+			Name:            device.Name,
+			Description:     device.Description,
+			Price:           device.Price.String(),
+			Currency:        device.Currency,
+			SellerID:        device.SellerID,
+			SellerName:      device.SellerName,
+			Contact:         device.Contact,
+			Category:        device.Category,
+			Status:          device.Status,
+			ModerationNote:  device.ModerationNote,
+			CreatedAt:       createdAt,
+			Attributes:      device.Attributes,
+			Condition:       device.Condition,
+			Defects:         strings.Join(device.Defects, ","),
+			Kit:             strings.Join(device.Kit, ","),
+			WarrantyUntil:   warrantyUntil,
+			IMEI:            device.IMEI,
+			IMEIStatus:      device.IMEIStatus,
+			City:            device.City,
+			Location:        location,
+			Delivery:        strings.Join(device.Delivery, ","),
+			ShippingCost:    shippingCost,
+			ContactVerified: device.ContactVerified,
+		})
+	}
+	sort.Slice(data.Devices, func(i, j int) bool { return data.Devices[i].ID < data.Devices[j].ID })
+
+	return data
+}
+
+// merge добавляет данные из другого файла, например devices.csv к users.csv
+func (data *exchangeData) merge(other exchangeData) {
+	// Номера строк в ошибках разбора сдвигаются на уже прочитанные записи раздела
+	for _, e := range other.parseErrors {
+		if e.Section == "users" {
+			e.Row += len(data.Users)
+		} else {
+			e.Row += len(data.Devices)
+		}
+		data.parseErrors = append(data.parseErrors, e)
+	}
+	data.Users = append(data.Users, other.Users...)
+	data.Devices = append(data.Devices, other.Devices...)
+}
+
+func writeExchangeJSON(w io.Writer, data exchangeData) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func readExchangeJSON(r io.Reader) (exchangeData, error) {
+	var data exchangeData
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return data, fmt.Errorf("некорректный JSON: %v", err)
+	}
+	return data, nil
+}
+
+func writeUsersCSV(w io.Writer, users []exchangeUser) error {
+	writer := csv.NewWriter(w)
+	writer.Write(userCSVColumns)
+	for _, user := range users {
+		writer.Write([]string{strconv.FormatInt(user.ID, 10), user.FirstName, user.LastName, user.Username,
+			user.Contact, user.Language, user.DisplayCurrency, user.City, user.Phone})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeDevicesCSV(w io.Writer, devices []exchangeDevice) error {
+	writer := csv.NewWriter(w)
+	writer.Write(deviceCSVColumns)
+	for _, device := range devices {
+		writer.Write([]string{strconv.Itoa(device.ID), device.Name, device.Description, device.Price,
+			device.Currency, strconv.FormatInt(device.SellerID, 10), device.SellerName, device.Contact,
+			device.Category, device.Status, device.ModerationNote, device.CreatedAt, encodeAttributes(device.Attributes),
+			device.Condition, device.Defects, device.Kit, device.WarrantyUntil, device.IMEI, device.IMEIStatus,
+			device.City, device.Location, device.Delivery, device.ShippingCost,
+			strconv.FormatBool(device.ContactVerified)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readExchangeCSV читает файл пользователей или объявлений: вид определяется по
+// заголовку, порядок колонок не важен, отсутствующие необязательные колонки пусты
+func readExchangeCSV(r io.Reader) (exchangeData, error) {
+	var data exchangeData
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return data, fmt.Errorf("не удалось прочитать заголовок CSV: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	_, isDevices := columns["seller_id"]
+	if _, ok := columns["id"]; !ok && !isDevices {
+		return data, fmt.Errorf("в заголовке CSV нет колонки id или seller_id")
+	}
+
+	section := "users"
+	if isDevices {
+		section = "devices"
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return data, fmt.Errorf("строка %d: %v", row+1, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		if isDevices {
+			id, idErr := parseOptionalInt(field("id"))
+			sellerID, sellerErr := strconv.ParseInt(field("seller_id"), 10, 64)
+			if idErr != nil || sellerErr != nil {
+				data.parseErrors = append(data.parseErrors, importError{Section: section, Row: row, Key: "import.error.bad_id"})
+				// Пустая запись сохраняет нумерацию строк в отчете
+				data.Devices = append(data.Devices, exchangeDevice{})
+				continue
+			}
+			data.Devices = append(data.Devices, exchangeDevice{
+				ID:              int(id),
+				Name:            field("name"),
+				Description:     field("description"),
+				Price:           field("price"),
+				Currency:        field("currency"),
+				SellerID:        sellerID,
+				SellerName:      field("seller_name"),
+				Contact:         field("contact"),
+				Category:        field("category"),
+				Status:          field("status"),
+				ModerationNote:  field("moderation_note"),
+				CreatedAt:       field("created_at"),
+				Attributes:      decodeAttributes(field("attributes")),
+				Condition:       field("condition"),
+				Defects:         field("defects"),
+				Kit:             field("kit"),
+				WarrantyUntil:   field("warranty_until"),
+				IMEI:            field("imei"),
+				IMEIStatus:      field("imei_status"),
+				City:            field("city"),
+				Location:        field("location"),
+				Delivery:        field("delivery"),
+				ShippingCost:    field("shipping_cost"),
+				ContactVerified: field("contact_verified") == "true",
+			})
+			continue
+		}
+
+		id, err := strconv.ParseInt(field("id"), 10, 64)
+		if err != nil {
+			data.parseErrors = append(data.parseErrors, importError{Section: section, Row: row, Key: "import.error.bad_id"})
+			data.Users = append(data.Users, exchangeUser{})
+			continue
+		}
+		data.Users = append(data.Users, exchangeUser{
+			ID:              id,
+			FirstName:       field("first_name"),
+			LastName:        field("last_name"),
+			Username:        field("username"),
+			Contact:         field("contact"),
+			Language:        field("language"),
+			DisplayCurrency: field("display_currency"),
+			City:            field("city"),
+			Phone:           field("phone"),
+		})
+	}
+
+	return data, nil
+}
+
+// readExchangeFile выбирает формат по расширению имени файла
+func readExchangeFile(name string, r io.Reader) (exchangeData, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return readExchangeJSON(r)
+	case ".csv":
+		return readExchangeCSV(r)
+	}
+	return exchangeData{}, fmt.Errorf("неизвестный формат файла %s, поддерживаются .json и .csv", name)
+}
+
+func parseOptionalInt(text string) (int64, error) {
+	if text == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(text, 10, 64)
+}
+
+// importError — ошибка в одной записи импорта; текст берется из локализации,
+// чтобы отчет можно было показать администратору на его языке
+type importError struct {
+	Section string
+	Row     int
+	Key     string
+	Args    []interface{}
+}
+
+type ImportReport struct {
+	DryRun           bool
+	UsersAdded       int
+	UsersExisting    int
+	DevicesAdded     int
+	DevicesDuplicate int
+	// Новые номера объявлений по номерам из файла
+	IDMap  map[int]int
+	Errors []importError
+}
+
+// importPlan — проверенные записи, готовые к сохранению. Объявления получают новые
+// номера при записи, а sourceIDs хранит их номера из файла для отчета.
+type importPlan struct {
+	users     []User
+	devices   []Device
+	sourceIDs []int
+	report    ImportReport
+}
+
+// planImport проверяет записи и отбрасывает дубликаты. Пользователи сопоставляются
+// по Telegram ID, который одинаков во всех окружениях; объявление считается дубликатом,
+// если у продавца уже есть объявление с тем же названием, ценой и категорией.
+func planImport(data exchangeData, existingUsers map[int64]User, existingDevices []Device, rates *CurrencyRates, now time.Time) importPlan {
+	plan := importPlan{report: ImportReport{IDMap: make(map[int]int), Errors: data.parseErrors}}
+	failed := make(map[string]bool)
+	for _, e := range data.parseErrors {
+		failed[fmt.Sprintf("%s:%d", e.Section, e.Row)] = true
+	}
+	reject := func(section string, row int, key string, args ...interface{}) {
+		plan.report.Errors = append(plan.report.Errors, importError{Section: section, Row: row, Key: key, Args: args})
+	}
+
+	sellers := make(map[int64]bool)
+	for id := range existingUsers {
+		sellers[id] = true
+	}
+
+	seenUsers := make(map[int64]bool)
+	for i, record := range data.Users {
+		row := i + 1
+		if failed[fmt.Sprintf("users:%d", row)] {
+			continue
+		}
+		switch {
+		case record.ID <= 0:
+			reject("users", row, "import.error.bad_id")
+			continue
+		case seenUsers[record.ID]:
+			reject("users", row, "import.error.duplicate_user", record.ID)
+			continue
+		case record.Language != "" && !isSupportedLanguage(record.Language):
+			reject("users", row, "import.error.bad_language", record.Language)
+			continue
+		case record.DisplayCurrency != "" && !rates.Supports(normalizeCurrency(record.DisplayCurrency)):
+			reject("users", row, "import.error.bad_currency", record.DisplayCurrency)
+			continue
+		case record.City != "" && resolveCityCode(record.City) == "":
+			reject("users", row, "import.error.bad_city", record.City)
+			continue
+		}
+		seenUsers[record.ID] = true
+
+		if _, ok := existingUsers[record.ID]; ok {
+			plan.report.UsersExisting++
+			continue
+		}
+		plan.users = append(plan.users, User{
+			ID:              record.ID,
+			FirstName:       record.FirstName,
+			LastName:        record.LastName,
+			Username:        record.Username,
+			Contact:         record.Contact,
+			Language:        record.Language,
+			DisplayCurrency: normalizeCurrency(record.DisplayCurrency),
+			City:            resolveCityCode(record.City),
+			Phone:           normalizePhone(record.Phone),
+		})
+		sellers[record.ID] = true
+	}
+
+	seenDevices := make(map[string]bool)
+	for _, device := range existingDevices {
+		seenDevices[deviceDuplicateKey(device)] = true
+	}
+
+	for i, record := range data.Devices {
+		row := i + 1
+		if failed[fmt.Sprintf("devices:%d", row)] {
+			continue
+		}
+
+		device, key, args := parseExchangeDevice(record, rates, now)
+		if key != "" {
+			reject("devices", row, key, args...)
+			continue
+		}
+		if !sellers[device.SellerID] {
+			reject("devices", row, "import.error.unknown_seller", device.SellerID)
+			continue
+		}
+
+		duplicateKey := deviceDuplicateKey(device)
+		if seenDevices[duplicateKey] {
+			plan.report.DevicesDuplicate++
+			continue
+		}
+		seenDevices[duplicateKey] = true
+
+		plan.devices = append(plan.devices, device)
+		plan.sourceIDs = append(plan.sourceIDs, record.ID)
+	}
+
+	return plan
+}
+
+// parseExchangeDevice возвращает ключ локализации и аргументы, если запись некорректна
+func parseExchangeDevice(record exchangeDevice, rates *CurrencyRates, now time.Time) (Device, string, []interface{}) {
+	device := Device{
+		Name:           strings.TrimSpace(record.Name),
+		Description:    record.Description,
+		Currency:       normalizeCurrency(record.Currency),
+		SellerID:       record.SellerID,
+		SellerName:     record.SellerName,
+		Contact:        record.Contact,
+		Category:       record.Category,
+		Status:         record.Status,
+		ModerationNote: record.ModerationNote,
+		CreatedAt:      now,
+		// Отметка переносится как есть: подтвердить номер заново при импорте нельзя
+		ContactVerified: record.ContactVerified,
+	}
+
+	if device.Name == "" {
+		return device, "import.error.no_name", nil
+	}
+	price, err := ParseMoney(record.Price)
+	if err != nil || price <= 0 {
+		return device, "import.error.bad_price", []interface{}{record.Price}
+	}
+	device.Price = price
+
+	if device.Currency == "" {
+		device.Currency = rates.Base
+	}
+	if !rates.Supports(device.Currency) {
+		return device, "import.error.bad_currency", []interface{}{record.Currency}
+	}
+	if _, ok := categoryList.Get(device.Category); !ok {
+		return device, "import.error.bad_category", []interface{}{record.Category}
+	}
+
+	attributes, badAttribute := validateAttributes(device.Category, record.Attributes)
+	if badAttribute != "" {
+		return device, "import.error.bad_attribute", []interface{}{badAttribute, record.Attributes[badAttribute]}
+	}
+	device.Attributes = attributes
+
+	if key, args := parseExchangeCondition(record, &device); key != "" {
+		return device, key, args
+	}
+
+	if strings.TrimSpace(record.IMEI) != "" {
+		imei, ok := normalizeIMEI(record.IMEI)
+		if !ok {
+			return device, "import.error.bad_imei", []interface{}{record.IMEI}
+		}
+		device.IMEI = imei
+		// Статус проверки переносится только вместе с номером
+		switch record.IMEIStatus {
+		case IMEIStatusVerified, IMEIStatusFlagged, IMEIStatusUnchecked:
+			device.IMEIStatus = record.IMEIStatus
+		}
+	}
+
+	if record.City != "" {
+		city, ok := resolveCity(record.City)
+		if !ok {
+			return device, "import.error.bad_city", []interface{}{record.City}
+		}
+		device.City = city.Code
+	}
+	if record.Location != "" {
+		location, ok := parseGeoPoint(record.Location)
+		if !ok {
+			return device, "import.error.bad_location", []interface{}{record.Location}
+		}
+		device.Location = location
+	}
+
+	if key, args := parseExchangeDelivery(record, &device); key != "" {
+		return device, key, args
+	}
+
+	switch device.Status {
+	case "":
+		device.Status = DeviceStatusActive
+	case DeviceStatusActive, DeviceStatusModeration, DeviceStatusRejected, DeviceStatusSold:
+	default:
+		return device, "import.error.bad_status", []interface{}{record.Status}
+	}
+
+	if record.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+		if err != nil {
+			return device, "import.error.bad_date", []interface{}{record.CreatedAt}
+		}
+		device.CreatedAt = createdAt
+	}
+
+	return device, "", nil
+}
+
+// parseExchangeCondition проверяет состояние, дефекты, комплект и гарантию; значения
+// принимаются кодами или названиями на любом языке
+func parseExchangeCondition(record exchangeDevice, device *Device) (string, []interface{}) {
+	if strings.TrimSpace(record.Condition) != "" {
+		grade, ok := normalizeCondition(record.Condition)
+		if !ok {
+			return "import.error.bad_condition", []interface{}{record.Condition}
+		}
+		device.Condition = grade
+	}
+
+	defects, ok := normalizeChecklist("defect.", deviceDefects, record.Defects)
+	if !ok {
+		return "import.error.bad_defects", []interface{}{record.Defects}
+	}
+	device.Defects = defects
+
+	kit, ok := normalizeChecklist("kit.", kitItems, record.Kit)
+	if !ok {
+		return "import.error.bad_kit", []interface{}{record.Kit}
+	}
+	device.Kit = kit
+
+	if strings.TrimSpace(record.WarrantyUntil) != "" && !isNoneWord(record.WarrantyUntil) {
+		warranty, ok := parseWarrantyDate(record.WarrantyUntil)
+		if !ok {
+			return "import.error.bad_warranty", []interface{}{record.WarrantyUntil}
+		}
+		device.WarrantyUntil = warranty
+	}
+	return "", nil
+}
+
+// parseExchangeDelivery разбирает способы получения; стоимость отправки допустима
+// только вместе с отправкой почтой
+func parseExchangeDelivery(record exchangeDevice, device *Device) (string, []interface{}) {
+	delivery, ok := normalizeDelivery(record.Delivery)
+	if !ok {
+		return "import.error.bad_delivery", []interface{}{record.Delivery}
+	}
+	device.Delivery = delivery
+
+	cost, ok := parseShippingCost(record.ShippingCost)
+	if !ok || (cost > 0 && !containsString(delivery, DeliveryShipping)) {
+		return "import.error.bad_shipping", []interface{}{record.ShippingCost}
+	}
+	device.ShippingCost = cost
+	return "", nil
+}
+
+func deviceDuplicateKey(device Device) string {
+	return fmt.Sprintf("%d|%s|%d|%s|%s", device.SellerID, normalizeListingText(device.Name),
+		device.Price, device.Currency, device.Category)
+}
+
+// maxReportErrors ограничивает число ошибок в отчете, чтобы он помещался в сообщение
+const maxReportErrors = 20
+
+func formatImportReport(lang string, report ImportReport) string {
+	var builder strings.Builder
+	if report.DryRun {
+		builder.WriteString(T(lang, "import.report.dry_run") + "\n")
+	}
+	builder.WriteString(T(lang, "import.report.summary", report.UsersAdded, report.UsersExisting,
+		report.DevicesAdded, report.DevicesDuplicate, len(report.Errors)))
+
+	for i, e := range report.Errors {
+		if i == maxReportErrors {
+			builder.WriteString("\n" + T(lang, "import.report.more_errors", len(report.Errors)-maxReportErrors))
+			break
+		}
+		builder.WriteString("\n" + T(lang, "import.report.error", T(lang, "import.section."+e.Section), e.Row, T(lang, e.Key, e.Args...)))
+	}
+	return builder.String()
+}
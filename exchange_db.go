@@ -0,0 +1,214 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+func (d *Database) ExportData() (exchangeData, error) {
+	users, err := d.GetUsers()
+	if err != nil {
+		return exchangeData{}, err
+	}
+	devices, err := d.GetAllDevices()
+	if err != nil {
+		return exchangeData{}, err
+	}
+	return newExchangeData(users, devices), nil
+}
+
+// ImportData сохраняет проверенные записи в одной транзакции: при ошибке базы не
+// сохраняется ничего. В режиме dryRun транзакция откатывается, а отчет показывает,
+// что было бы импортировано, включая новые номера объявлений.
+func (d *Database) ImportData(data exchangeData, rates *CurrencyRates, dryRun bool) (ImportReport, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer tx.Rollback()
+
+	existingUsers := make(map[int64]User)
+	rows, err := tx.Query(`SELECT id FROM users`)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return ImportReport{}, err
+		}
+		existingUsers[id] = User{ID: id}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ImportReport{}, err
+	}
+
+	rows, err = tx.Query(`SELECT ` + deviceColumns + ` FROM devices`)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	existingDevices, err := scanDevices(rows)
+	rows.Close()
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	plan := planImport(data, existingUsers, existingDevices, rates, time.Now())
+
+	for _, user := range plan.users {
+		_, err := tx.Exec(d.dialect.rebind(`INSERT INTO users (id, first_name, last_name, username, contact, language, display_currency, city, phone)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			user.ID, user.FirstName, user.LastName, user.Username, user.Contact, user.Language, user.DisplayCurrency, user.City, user.Phone)
+		if err != nil {
+			return ImportReport{}, fmt.Errorf("пользователь %d: %v", user.ID, err)
+		}
+	}
+
+	for i, device := range plan.devices {
+		var id int
+		err := tx.QueryRow(d.dialect.rebind(insertDeviceQuery), insertDeviceArgs(device)...).Scan(&id)
+		if err != nil {
+			return ImportReport{}, fmt.Errorf("объявление %q: %v", device.Name, err)
+		}
+		if err := d.insertAttributes(tx, id, device.Attributes); err != nil {
+			return ImportReport{}, fmt.Errorf("характеристики объявления %q: %v", device.Name, err)
+		}
+		if sourceID := plan.sourceIDs[i]; sourceID != 0 {
+			plan.report.IDMap[sourceID] = id
+		}
+	}
+
+	report := plan.report
+	report.DryRun = dryRun
+	report.UsersAdded = len(plan.users)
+	report.DevicesAdded = len(plan.devices)
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// runExchangeCommand выполняет служебные команды:
+//
+//	export json <файл>   — пользователи и объявления одним JSON-файлом
+//	export csv <каталог> — users.csv и devices.csv
+//	import <файл>...     — JSON или CSV; с флагом -dry-run изменения отменяются
+func runExchangeCommand(config Config, args []string, dryRun bool) error {
+	db, err := NewDatabase(config.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "export":
+		if len(args) < 3 {
+			return fmt.Errorf("использование: export json <файл> или export csv <каталог>")
+		}
+		data, err := db.ExportData()
+		if err != nil {
+			return err
+		}
+		if err := writeExchangeFiles(args[1], args[2], data); err != nil {
+			return err
+		}
+		fmt.Printf("Выгружено пользователей: %d, объявлений: %d\n", len(data.Users), len(data.Devices))
+	case "import":
+		if len(args) < 2 {
+			return fmt.Errorf("укажите файлы для импорта: import <файл>...")
+		}
+		var data exchangeData
+		for _, path := range args[1:] {
+			fileData, err := readExchangePath(path)
+			if err != nil {
+				return err
+			}
+			data.merge(fileData)
+		}
+
+		rates, err := LoadCurrencyRates(config.RatesFile)
+		if err != nil {
+			return err
+		}
+		report, err := db.ImportData(data, rates, dryRun)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(formatImportReport(defaultLanguage, report))
+		sourceIDs := make([]int, 0, len(report.IDMap))
+		for id := range report.IDMap {
+			sourceIDs = append(sourceIDs, id)
+		}
+		sort.Ints(sourceIDs)
+		for _, id := range sourceIDs {
+			fmt.Printf("объявление #%d -> #%d\n", id, report.IDMap[id])
+		}
+	default:
+		return fmt.Errorf("неизвестная команда %q, доступны export и import", args[0])
+	}
+	return nil
+}
+
+func readExchangePath(path string) (exchangeData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return exchangeData{}, err
+	}
+	defer file.Close()
+
+	data, err := readExchangeFile(path, file)
+	if err != nil {
+		return data, fmt.Errorf("%s: %v", path, err)
+	}
+	return data, nil
+}
+
+func writeExchangeFiles(format, path string, data exchangeData) error {
+	switch format {
+	case "json":
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := writeExchangeJSON(file, data); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	case "csv":
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+		users, err := os.Create(filepath.Join(path, "users.csv"))
+		if err != nil {
+			return err
+		}
+		if err := writeUsersCSV(users, data.Users); err != nil {
+			users.Close()
+			return err
+		}
+		if err := users.Close(); err != nil {
+			return err
+		}
+
+		devices, err := os.Create(filepath.Join(path, "devices.csv"))
+		if err != nil {
+			return err
+		}
+		if err := writeDevicesCSV(devices, data.Devices); err != nil {
+			devices.Close()
+			return err
+		}
+		return devices.Close()
+	}
+	return fmt.Errorf("неизвестный формат %q, доступны json и csv", format)
+}
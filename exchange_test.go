@@ -0,0 +1,127 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportBetweenDatabases(t *testing.T) {
+	source := openTestDatabase(t, filepath.Join(t.TempDir(), "source.db"))
+	target := openTestDatabase(t, filepath.Join(t.TempDir(), "target.db"))
+	rates := DefaultCurrencyRates()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := source.SaveUser(User{ID: 1, FirstName: "Иван", Language: LangEN, City: "kazan"}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		source.SaveDevice(Device{Name: "Удаленное", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+			Category: CategoryOther, Status: DeviceStatusActive, CreatedAt: createdAt})
+	}
+	source.exec(`DELETE FROM devices`)
+	sourceID, err := source.SaveDevice(Device{Name: "iPhone 13", Description: "Как новый", Price: 5999990,
+		Currency: CurrencyRUB, SellerID: 1, Category: CategorySmartphone, Status: DeviceStatusModeration, CreatedAt: createdAt,
+		Condition: "good", Kit: []string{"box"}, IMEI: "490154203237518", IMEIStatus: IMEIStatusVerified,
+		City: "kazan", Location: GeoPoint{Latitude: 55.79, Longitude: 49.12}})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+
+	data, err := source.ExportData()
+	if err != nil {
+		t.Fatalf("ExportData: %v", err)
+	}
+
+	// CSV проходит через файлы так же, как при выгрузке командой export csv
+	var users, devices bytes.Buffer
+	writeUsersCSV(&users, data.Users)
+	writeDevicesCSV(&devices, data.Devices)
+	var parsed exchangeData
+	for name, buf := range map[string]*bytes.Buffer{"users.csv": &users, "devices.csv": &devices} {
+		fileData, err := readExchangeFile(name, buf)
+		if err != nil {
+			t.Fatalf("readExchangeFile(%s): %v", name, err)
+		}
+		parsed.merge(fileData)
+	}
+
+	dryReport, err := target.ImportData(parsed, rates, true)
+	if err != nil || !dryReport.DryRun || dryReport.DevicesAdded != 1 {
+		t.Fatalf("dry-run: %+v, %v", dryReport, err)
+	}
+	if all, _ := target.GetAllDevices(); len(all) != 0 {
+		t.Fatalf("dry-run сохранил объявления: %+v", all)
+	}
+
+	report, err := target.ImportData(parsed, rates, false)
+	if err != nil {
+		t.Fatalf("ImportData: %v", err)
+	}
+	if report.UsersAdded != 1 || report.DevicesAdded != 1 || len(report.Errors) != 0 {
+		t.Fatalf("отчет = %+v", report)
+	}
+
+	newID := report.IDMap[sourceID]
+	device, found, err := target.GetDeviceByID(newID)
+	if err != nil || !found {
+		t.Fatalf("GetDeviceByID(%d): %v, %v", newID, found, err)
+	}
+	if newID == sourceID || device.Price != 5999990 || device.Status != DeviceStatusModeration || !device.CreatedAt.Equal(createdAt) {
+		t.Fatalf("импортировано %+v (номер %d в источнике)", device, sourceID)
+	}
+	if device.Condition != "good" || len(device.Kit) != 1 || device.IMEI != "490154203237518" ||
+		device.City != "kazan" || device.Location.Latitude != 55.79 {
+		t.Fatalf("состояние, IMEI и город не перенесены: %+v", device)
+	}
+	if users, _ := target.GetUsers(); users[1].City != "kazan" {
+		t.Fatalf("пользователи после импорта: %+v", users)
+	}
+
+	again, err := target.ImportData(parsed, rates, false)
+	if err != nil || again.UsersExisting != 1 || again.DevicesDuplicate != 1 || again.DevicesAdded != 0 {
+		t.Fatalf("повторный импорт: %+v, %v", again, err)
+	}
+}
+
+func TestImportReportsInvalidRows(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+
+	csvData := "seller_id,name,price,currency,category\n" +
+		"1,Pixel 7,25000,RUB,smartphone\n" +
+		"x,Pixel 8,25000,RUB,smartphone\n" +
+		"2,,25000,RUB,smartphone\n" +
+		"2,Pixel 6,бесплатно,RUB,smartphone\n" +
+		"2,Pixel 5,25000,RUB,laptop\n"
+	data, err := readExchangeFile("devices.csv", strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("readExchangeFile: %v", err)
+	}
+	data.merge(exchangeData{Users: []exchangeUser{{ID: 2}}})
+
+	report, err := db.ImportData(data, DefaultCurrencyRates(), false)
+	if err != nil {
+		t.Fatalf("ImportData: %v", err)
+	}
+
+	wantKeys := map[int]string{
+		1: "import.error.unknown_seller",
+		2: "import.error.bad_id",
+		3: "import.error.no_name",
+		4: "import.error.bad_price",
+		5: "import.error.bad_category",
+	}
+	if len(report.Errors) != len(wantKeys) || report.DevicesAdded != 0 {
+		t.Fatalf("отчет = %+v", report)
+	}
+	for _, e := range report.Errors {
+		if wantKeys[e.Row] != e.Key {
+			t.Errorf("строка %d: ошибка %s, ожидалась %s", e.Row, e.Key, wantKeys[e.Row])
+		}
+	}
+}
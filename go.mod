@@ -4,4 +4,6 @@ go 1.24.2
 
 require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
 
-require github.com/mattn/go-sqlite3 v1.14.28 // indirect
+require github.com/mattn/go-sqlite3 v1.14.28
+
+require github.com/lib/pq v1.12.3
@@ -0,0 +1,1820 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func handleUpdate(sender *Sender, guard *FloodGuard, update tgbotapi.Update, state *BotState) {
+	var from *tgbotapi.User
+	if update.Message != nil {
+		from = update.Message.From
+	} else if update.CallbackQuery != nil {
+		from = update.CallbackQuery.From
+	}
+	if from == nil {
+		return
+	}
+
+	lang := userLanguage(state, from)
+
+	if allowed, warn := guard.Allow(from.ID); !allowed {
+		if update.CallbackQuery != nil {
+			sender.Send(tgbotapi.NewCallback(update.CallbackQuery.ID, T(lang, "flood.callback")))
+		} else if warn {
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, T(lang, "flood.message"))
+			sender.Send(msg)
+		}
+		return
+	}
+
+	if update.Message != nil {
+		handleMessage(sender, update.Message, state, lang)
+	} else if update.CallbackQuery != nil {
+		handleCallbackQuery(sender, update.CallbackQuery, state, lang)
+	}
+}
+
+func handleMessage(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	userState := state.GetUserState(userID)
+
+	if message.IsCommand() {
+		switch message.Command() {
+		case "start":
+			handleStart(sender, message, state, lang)
+		case "help":
+			handleHelp(sender, message, state, lang)
+		case "moderation":
+			handleModeration(sender, message, state, lang)
+		case "language":
+			handleLanguage(sender, message, state, lang)
+		case "currency":
+			handleCurrency(sender, message, state, lang)
+		case "city":
+			handleCity(sender, message, state, lang)
+		case "backup":
+			handleBackup(sender, message, state, lang)
+		case "export":
+			handleExport(sender, message, state, lang)
+		case "import":
+			handleImport(sender, message, state, lang)
+		case "categories":
+			handleCategories(sender, message, state, lang)
+		case "category":
+			handleCategory(sender, message, state, lang)
+		default:
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "command.unknown"))
+			sender.Send(msg)
+		}
+		return
+	}
+
+	if message.Document != nil && userState == "" {
+		handleBulkUpload(sender, message, state, lang)
+		return
+	}
+
+	if message.Location != nil {
+		handleLocation(sender, message, state, lang)
+		return
+	}
+
+	if message.Contact != nil {
+		handleSharedContact(sender, message, state, lang)
+		return
+	}
+
+	switch userState {
+	case "waiting_device_name":
+		state.SetWaitingInput(userID, "name", message.Text)
+		askNextAttribute(sender, message.Chat.ID, userID, state, lang, "")
+
+	case "waiting_device_attribute":
+		def, ok := currentAttribute(state, userID)
+		if !ok {
+			askNextAttribute(sender, message.Chat.ID, userID, state, lang, "")
+			return
+		}
+		value, ok := normalizeAttributeValue(def, message.Text)
+		if !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "attr.invalid")+"\n"+formatAttributeHint(lang, def))
+			msg.ReplyMarkup = getAttributeKeyboard(lang, def)
+			sender.Send(msg)
+			return
+		}
+		recordAttribute(state, userID, def, value)
+		askNextAttribute(sender, message.Chat.ID, userID, state, lang, "")
+
+	case "waiting_device_condition":
+		grade, ok := normalizeCondition(message.Text)
+		if !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_condition"))
+			msg.ReplyMarkup = getConditionKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		recordCondition(sender, message.Chat.ID, userID, state, lang, grade)
+
+	case "waiting_device_defects", "waiting_device_kit", "waiting_device_delivery":
+		// Пункты списка отмечаются кнопками; на текст бот напоминает о них
+		askChecklist(sender, message.Chat.ID, userID, state, lang, userState)
+
+	case "waiting_device_warranty":
+		if isNoneWord(message.Text) {
+			askIMEI(sender, message.Chat.ID, userID, state, lang)
+			return
+		}
+		date, ok := parseWarrantyDate(message.Text)
+		if !ok || !hasWarranty(Device{WarrantyUntil: date}, time.Now()) {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_warranty"))
+			msg.ReplyMarkup = getWarrantyKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		state.SetWaitingInput(userID, "warranty", date.Format("2006-01-02"))
+		askIMEI(sender, message.Chat.ID, userID, state, lang)
+
+	case "waiting_device_imei":
+		imei, ok := normalizeIMEI(message.Text)
+		if !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_imei"))
+			msg.ReplyMarkup = getIMEIKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		state.SetWaitingInput(userID, "imei", imei)
+		askCity(sender, message.Chat.ID, userID, state, lang)
+
+	case "waiting_device_city":
+		city, ok := resolveCity(message.Text)
+		if !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_city"))
+			msg.ReplyMarkup = getCityKeyboard(lang, "scity_", state.GetUser(userID).City, "")
+			sender.Send(msg)
+			return
+		}
+		state.SetWaitingInput(userID, "city", city.Code)
+		askDescription(sender, message.Chat.ID, userID, state, lang)
+
+	case "waiting_device_description":
+		state.SetWaitingInput(userID, "description", message.Text)
+		state.SetUserState(userID, "waiting_device_price")
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.ask_price"))
+		sender.Send(msg)
+
+	case "waiting_device_price":
+		price, err := ParseMoney(message.Text)
+		if err != nil || price <= 0 {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_price"))
+			sender.Send(msg)
+			return
+		}
+
+		state.SetWaitingInput(userID, "price", price.String())
+		state.SetUserState(userID, "waiting_device_currency")
+
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.ask_currency"))
+		msg.ReplyMarkup = getCurrencyKeyboard(state.Rates, "cur_", "")
+		sender.Send(msg)
+
+	case "waiting_device_shipping_cost":
+		cost, ok := parseShippingCost(message.Text)
+		if !ok {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.invalid_shipping"))
+			msg.ReplyMarkup = getShippingCostKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		state.SetWaitingInput(userID, "shipping_cost", cost.String())
+		askContact(sender, message.Chat.ID, userID, state, lang)
+
+	case "waiting_device_contact":
+		acceptListingContact(sender, message.Chat.ID, message.From, state, lang, strings.TrimSpace(message.Text))
+
+	case "waiting_import_file":
+		handleImportFile(sender, message, state, lang)
+
+	case "waiting_search_query":
+		runSearch(sender, message.Chat.ID, userID, state, lang, message.Text)
+		state.SetUserState(userID, "")
+
+	default:
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "menu.choose_action"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+	}
+}
+
+// runSearch выполняет поисковый запрос с фильтрами и показывает найденные объявления
+func runSearch(sender *Sender, chatID, userID int64, state *BotState, lang, query string) {
+	displayCurrency := state.GetUser(userID).DisplayCurrency
+	text, filters := parseSearchQuery(query, state.Rates, displayCurrency)
+	devices, err := state.SearchDevices(text)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	var foundDevices []Device
+	for _, device := range devices {
+		if matchesFilters(device, filters) {
+			foundDevices = append(foundDevices, device)
+		}
+	}
+
+	msg := newHTMLMessage(chatID, formatSearchHeader(lang, query, len(foundDevices)))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	if len(foundDevices) > 0 {
+		msg.ReplyMarkup = getSearchKeyboard(lang, sender.BotUsername(), query)
+	}
+	sender.Send(msg)
+
+	for _, device := range foundDevices {
+		deviceMsg := newHTMLMessage(chatID, formatDeviceForBuyer(lang, device, state.Rates, displayCurrency))
+		deviceMsg.ReplyMarkup = getDeviceCardKeyboard(lang, sender.BotUsername(), device)
+		sender.Send(deviceMsg)
+	}
+}
+
+func handleCallbackQuery(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	userID := callbackQuery.From.ID
+	data := callbackQuery.Data
+
+	callback := tgbotapi.NewCallback(callbackQuery.ID, "")
+	sender.Send(callback)
+
+	chatID := callbackQuery.Message.Chat.ID
+
+	if strings.HasPrefix(data, "cur_") {
+		if state.GetUserState(userID) != "waiting_device_currency" {
+			return
+		}
+
+		currency := normalizeCurrency(strings.TrimPrefix(data, "cur_"))
+		if !state.Rates.Supports(currency) {
+			msg := tgbotapi.NewMessage(chatID, T(lang, "currency.unknown"))
+			msg.ReplyMarkup = getCurrencyKeyboard(state.Rates, "cur_", "")
+			sender.Send(msg)
+			return
+		}
+
+		state.SetWaitingInput(userID, "currency", currency)
+		askChecklist(sender, chatID, userID, state, lang, "waiting_device_delivery")
+		return
+	}
+
+	if strings.HasPrefix(data, "attr_") {
+		if state.GetUserState(userID) != "waiting_device_attribute" {
+			return
+		}
+		if def, ok := currentAttribute(state, userID); ok {
+			value := strings.TrimPrefix(data, "attr_")
+			if value == "skip" {
+				value = ""
+			}
+			recordAttribute(state, userID, def, value)
+		}
+		askNextAttribute(sender, chatID, userID, state, lang, "")
+		return
+	}
+
+	if strings.HasPrefix(data, "cond_") || strings.HasPrefix(data, "defect_") || strings.HasPrefix(data, "kit_") || data == "warranty_none" || data == "imei_skip" ||
+		strings.HasPrefix(data, "scity_") || strings.HasPrefix(data, "delivery_") || data == "shipping_free" {
+		handleConditionCallback(sender, callbackQuery, state, lang)
+		return
+	}
+
+	if strings.HasPrefix(data, "cat_") || strings.HasPrefix(data, "catpick_") || strings.HasPrefix(data, "catall_") {
+		if state.GetUserState(userID) == "waiting_device_category" {
+			handleSellCategory(sender, callbackQuery, state, lang)
+		} else {
+			handleBrowseCategory(sender, callbackQuery, state, lang)
+		}
+		return
+	}
+
+	switch data {
+	case "browse_devices":
+		showCategoryBrowser(sender, chatID, state, lang, "")
+
+	case "browse_all_devices":
+		devices, err := state.GetDevices()
+		if err != nil {
+			sendStorageError(sender, chatID, lang, err)
+			return
+		}
+		displayCurrency := state.GetUser(userID).DisplayCurrency
+		if len(devices) == 0 {
+			msg := tgbotapi.NewMessage(chatID, T(lang, "browse.empty"))
+			msg.ReplyMarkup = getBackKeyboard(lang)
+			sender.Send(msg)
+		} else {
+			msg := tgbotapi.NewMessage(chatID, TN(lang, "browse.header", len(devices)))
+			sender.Send(msg)
+
+			for _, device := range devices {
+				deviceMsg := newHTMLMessage(chatID, formatDeviceForBuyer(lang, device, state.Rates, displayCurrency))
+				deviceMsg.ReplyMarkup = getDeviceCardKeyboard(lang, sender.BotUsername(), device)
+				sender.Send(deviceMsg)
+			}
+
+			backMsg := tgbotapi.NewMessage(chatID, T(lang, "browse.back"))
+			backMsg.ReplyMarkup = getListingKeyboard(lang, sortAllDevices)
+			sender.Send(backMsg)
+		}
+
+	case "sell_device":
+		usage, err := getListingUsage(state, userID, time.Now())
+		if err != nil {
+			sendStorageError(sender, chatID, lang, err)
+			return
+		}
+
+		if quotaMessage := checkListingQuota(lang, state.Config, userID, usage); quotaMessage != "" {
+			msg := tgbotapi.NewMessage(chatID, quotaMessage)
+			msg.ReplyMarkup = getMainKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+
+		state.SetUserState(userID, "waiting_device_category")
+		state.ClearWaitingInput(userID)
+
+		msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_category"))
+		msg.ReplyMarkup = getCategoryKeyboard(lang, "")
+		sender.Send(msg)
+
+	case "my_devices":
+		userDevices, err := state.GetUserDevices(userID)
+		if err != nil {
+			sendStorageError(sender, chatID, lang, err)
+			return
+		}
+		if len(userDevices) == 0 {
+			msg := tgbotapi.NewMessage(chatID, T(lang, "my.empty"))
+			msg.ReplyMarkup = getMainKeyboard(lang)
+			sender.Send(msg)
+		} else {
+			msg := tgbotapi.NewMessage(chatID, TN(lang, "my.header", len(userDevices)))
+			sender.Send(msg)
+
+			for _, device := range userDevices {
+				deviceMsg := newHTMLMessage(chatID, formatDeviceInfo(lang, device)+formatDeviceStatus(lang, device))
+				deviceMsg.ReplyMarkup = getDeviceActionsKeyboard(lang, device)
+				sender.Send(deviceMsg)
+			}
+
+			backMsg := tgbotapi.NewMessage(chatID, T(lang, "my.back"))
+			backMsg.ReplyMarkup = getMainMenuButton(lang)
+			sender.Send(backMsg)
+		}
+
+	case "search_devices":
+		state.SetUserState(userID, "waiting_search_query")
+
+		msg := tgbotapi.NewMessage(chatID, T(lang, "search.ask"))
+		msg.ReplyMarkup = getMainMenuButton(lang)
+		sender.Send(msg)
+
+	case "help":
+		handleHelp(sender, callbackQuery.Message, state, lang)
+
+	case "back_to_main":
+		msg := tgbotapi.NewMessage(chatID, T(lang, "menu.main"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+
+	case "back_to_categories":
+		showCategoryBrowser(sender, chatID, state, lang, "")
+
+	default:
+		if strings.HasPrefix(data, "lang_") {
+			handleLanguageChoice(sender, callbackQuery, state)
+			return
+		}
+
+		if strings.HasPrefix(data, "ucity_") {
+			handleCityChoice(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, "dcur_") {
+			handleCurrencyChoice(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, listingByPrice) || strings.HasPrefix(data, listingNearby) || strings.HasPrefix(data, listingInCity) {
+			handleSortedListing(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, "bulk_publish_") || strings.HasPrefix(data, "bulk_cancel_") {
+			handleBulkDecision(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, "approve_device_") || strings.HasPrefix(data, "reject_device_") {
+			handleModerationDecision(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, "seller_") || strings.HasPrefix(data, "rate_") {
+			handleSellerCallback(sender, callbackQuery, state, lang)
+			return
+		}
+
+		if strings.HasPrefix(data, "sold_device_") {
+			deviceID, ok := findOwnDevice(sender, chatID, userID, state, lang, strings.TrimPrefix(data, "sold_device_"))
+			if !ok {
+				return
+			}
+			_, updated, err := state.SetDeviceStatus(deviceID, DeviceStatusActive, DeviceStatusSold)
+			if err != nil {
+				sendStorageError(sender, chatID, lang, err)
+				return
+			}
+			// Объявление на модерации, уже проданное или удаленное после нажатия кнопки
+			text := T(lang, "device.sold")
+			if !updated {
+				text = T(lang, "device.not_active")
+			}
+			msg := tgbotapi.NewMessage(chatID, text)
+			msg.ReplyMarkup = getMainKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+
+		if strings.HasPrefix(data, "remove_device_") {
+			deviceID, ok := findOwnDevice(sender, chatID, userID, state, lang, strings.TrimPrefix(data, "remove_device_"))
+			if !ok {
+				return
+			}
+
+			removed, err := state.RemoveDevice(deviceID)
+			if err != nil {
+				sendStorageError(sender, chatID, lang, err)
+				return
+			}
+
+			if removed {
+				msg := tgbotapi.NewMessage(chatID, T(lang, "device.removed"))
+				msg.ReplyMarkup = getMainKeyboard(lang)
+				sender.Send(msg)
+			} else {
+				msg := tgbotapi.NewMessage(chatID, T(lang, "device.remove_failed"))
+				msg.ReplyMarkup = getMainKeyboard(lang)
+				sender.Send(msg)
+			}
+		}
+	}
+}
+
+// findOwnDevice находит объявление из callback и проверяет, что оно принадлежит
+// пользователю; иначе сообщает об ошибке и возвращает false
+func findOwnDevice(sender *Sender, chatID, userID int64, state *BotState, lang, idStr string) (int, bool) {
+	var deviceID int
+	fmt.Sscanf(idStr, "%d", &deviceID)
+
+	device, found, err := state.FindDeviceByID(deviceID)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return 0, false
+	}
+	if !found {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "device.not_found"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return 0, false
+	}
+
+	if device.SellerID != userID {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "device.not_owner"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return 0, false
+	}
+	return deviceID, true
+}
+
+func handleStart(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	user := state.GetUser(userID)
+	user.FirstName = message.From.FirstName
+	user.LastName = message.From.LastName
+	user.Username = message.From.UserName
+
+	// Приветствие не зависит от профиля, поэтому сбой записи только логируется
+	if err := state.SaveUser(user); err != nil {
+		log.Printf("Не удалось сохранить профиль пользователя %d: %v", userID, err)
+	}
+
+	if handleStartPayload(sender, message, state, lang) {
+		return
+	}
+
+	msg := newHTMLMessage(message.Chat.ID, renderMessage(lang, "welcome", message.From))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+func handleModeration(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	if !state.Config.IsAdmin(message.From.ID) {
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "moderation.admin_only_command"))
+		sender.Send(msg)
+		return
+	}
+
+	devices, err := state.GetDevicesByStatus(DeviceStatusModeration)
+	if err != nil {
+		sendStorageError(sender, message.Chat.ID, lang, err)
+		return
+	}
+	if len(devices) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "moderation.empty"))
+		sender.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, TN(lang, "moderation.header", len(devices)))
+	sender.Send(msg)
+
+	for _, device := range devices {
+		deviceMsg := newHTMLMessage(message.Chat.ID, formatModerationInfo(lang, device))
+		deviceMsg.ReplyMarkup = getModerationKeyboard(lang, device.ID)
+		sender.Send(deviceMsg)
+	}
+}
+
+func handleModerationDecision(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	chatID := callbackQuery.Message.Chat.ID
+	data := callbackQuery.Data
+
+	if !state.Config.IsAdmin(callbackQuery.From.ID) {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "moderation.admin_only_action"))
+		sender.Send(msg)
+		return
+	}
+
+	status := DeviceStatusActive
+	idStr := strings.TrimPrefix(data, "approve_device_")
+	if strings.HasPrefix(data, "reject_device_") {
+		status = DeviceStatusRejected
+		idStr = strings.TrimPrefix(data, "reject_device_")
+	}
+
+	var deviceID int
+	fmt.Sscanf(idStr, "%d", &deviceID)
+
+	device, found, err := state.FindDeviceByID(deviceID)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	if !found {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "device.not_found"))
+		sender.Send(msg)
+		return
+	}
+
+	// Кнопки старого уведомления или второго модератора не должны возвращать
+	// проданное или отклоненное объявление на витрину и снова писать продавцу
+	device, ok, err := state.SetDeviceStatus(deviceID, DeviceStatusModeration, status)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	if !ok {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "moderation.already_handled", deviceID)))
+		return
+	}
+
+	if status == DeviceStatusActive {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "moderation.approved", device.ID)))
+		sellerLang := recipientLanguage(state, device.SellerID)
+		sender.Send(newHTMLMessage(device.SellerID, renderMessage(sellerLang, "moderation_approved", newDeviceView(sellerLang, device))))
+	} else {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "moderation.rejected", device.ID)))
+		sellerLang := recipientLanguage(state, device.SellerID)
+		sender.Send(newHTMLMessage(device.SellerID, renderMessage(sellerLang, "moderation_rejected", newDeviceView(sellerLang, device))))
+	}
+}
+
+// Telegram не принимает от ботов файлы больше 50 МБ
+const maxBackupUploadSize = 50 << 20
+
+func handleBackup(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	if !state.Config.IsAdmin(message.From.ID) {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "moderation.admin_only_command")))
+		return
+	}
+
+	path, err := state.Backup()
+	if errors.Is(err, errBackupUnsupported) {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "backup.unsupported")))
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка резервного копирования по команде администратора %d: %v", message.From.ID, err)
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "backup.failed")))
+		return
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > maxBackupUploadSize {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "backup.too_large", path)))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FilePath(path))
+	doc.Caption = T(lang, "backup.done", filepath.Base(path))
+	sender.Send(doc)
+}
+
+func handleExport(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	chatID := message.Chat.ID
+	if !state.Config.IsAdmin(message.From.ID) {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "moderation.admin_only_command")))
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "export.usage")))
+		return
+	}
+
+	data, err := state.ExportData()
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	stamp := time.Now().Format("20060102-150405")
+	caption := T(lang, "export.done", len(data.Users), len(data.Devices))
+	if format == "json" {
+		var buf bytes.Buffer
+		writeExchangeJSON(&buf, data)
+		doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "marketplace-" + stamp + ".json", Bytes: buf.Bytes()})
+		doc.Caption = caption
+		sender.Send(doc)
+		return
+	}
+
+	var users, devices bytes.Buffer
+	writeUsersCSV(&users, data.Users)
+	writeDevicesCSV(&devices, data.Devices)
+	sender.Send(tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "users-" + stamp + ".csv", Bytes: users.Bytes()}))
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "devices-" + stamp + ".csv", Bytes: devices.Bytes()})
+	doc.Caption = caption
+	sender.Send(doc)
+}
+
+func handleCategories(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	if !state.Config.IsAdmin(message.From.ID) {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "moderation.admin_only_command")))
+		return
+	}
+	sender.Send(tgbotapi.NewMessage(message.Chat.ID, formatCategoryList(lang, categoryList.All())))
+}
+
+// handleCategory создает, переименовывает, переставляет, включает и выключает
+// категории каталога; синтаксис описан у applyCategoryCommand
+func handleCategory(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	chatID := message.Chat.ID
+	if !state.Config.IsAdmin(message.From.ID) {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "moderation.admin_only_command")))
+		return
+	}
+
+	category, key, args := applyCategoryCommand(categoryList.All(), message.CommandArguments())
+	if key != "" {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, key, args...)))
+		return
+	}
+
+	if err := state.SaveCategory(category); err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	sender.Send(tgbotapi.NewMessage(chatID, formatCategoryList(lang, categoryList.All())))
+}
+
+// handleImport ждет от администратора файл JSON или CSV; с аргументом dry-run
+// файл только проверяется, а изменения отменяются
+func handleImport(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	if !state.Config.IsAdmin(userID) {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "moderation.admin_only_command")))
+		return
+	}
+
+	dryRun := strings.TrimSpace(message.CommandArguments()) == "dry-run"
+	state.ClearWaitingInput(userID)
+	state.SetUserState(userID, "waiting_import_file")
+
+	text := T(lang, "import.ask_file")
+	if dryRun {
+		state.SetWaitingInput(userID, "dry_run", "1")
+		text += "\n" + T(lang, "import.dry_run_note")
+	}
+	sender.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+func handleImportFile(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
+	dryRun := state.GetWaitingInput(userID)["dry_run"] == "1"
+	state.ClearWaitingInput(userID)
+	state.SetUserState(userID, "")
+
+	if !state.Config.IsAdmin(userID) {
+		return
+	}
+
+	// Любое сообщение без файла отменяет импорт, чтобы администратор не застрял в этом шаге
+	if message.Document == nil {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "import.cancelled"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+	if message.Document.FileSize > maxDownloadSize {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.too_large")))
+		return
+	}
+
+	content, err := sender.Download(message.Document.FileID)
+	if err != nil {
+		log.Printf("Не удалось скачать файл импорта от %d: %v", userID, err)
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.download_failed")))
+		return
+	}
+
+	data, err := readExchangeFile(message.Document.FileName, bytes.NewReader(content))
+	if err != nil {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.bad_file", err)))
+		return
+	}
+
+	report, err := state.ImportData(data, dryRun)
+	if err != nil {
+		log.Printf("Ошибка импорта от администратора %d: %v", userID, err)
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.failed")))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, formatImportReport(lang, report))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+// handleBulkUpload разбирает файл с объявлениями от проверенного продавца и показывает
+// предпросмотр; публикация происходит только после подтверждения
+func handleBulkUpload(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
+	document := message.Document
+
+	if !canBulkUpload(state.Config, userID) {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.verified_only")))
+		return
+	}
+	if !isBulkUploadFile(document.FileName) {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.unsupported_format")))
+		return
+	}
+	if document.FileSize > maxDownloadSize {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.too_large")))
+		return
+	}
+
+	content, err := sender.Download(document.FileID)
+	if err != nil {
+		log.Printf("Не удалось скачать файл объявлений от %d: %v", userID, err)
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "import.download_failed")))
+		return
+	}
+
+	rows, err := readSpreadsheet(document.FileName, content)
+	if err != nil {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.bad_file", err)))
+		return
+	}
+	upload, err := parseBulkUpload(rows, message.From, state.Rates, time.Now())
+	if err != nil {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.bad_file", err)))
+		return
+	}
+	seller := state.GetUser(userID)
+	for i := range upload.Devices {
+		upload.Devices[i].ContactVerified = contactVerified(seller, upload.Devices[i].Contact)
+	}
+	if len(upload.Devices) > maxBulkRows {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.too_many", maxBulkRows)))
+		return
+	}
+
+	// Лимиты проверяются уже в предпросмотре, чтобы не предлагать публикацию, которая
+	// заведомо не пройдет
+	usage, err := getListingUsage(state, userID, time.Now())
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	left := listingQuotaLeft(state.Config, userID, usage)
+
+	msg := tgbotapi.NewMessage(chatID, formatBulkPreview(lang, upload, left))
+	if len(upload.Devices) > 0 && (left < 0 || len(upload.Devices) <= left) {
+		token := state.Uploads.Put(userID, upload.Devices)
+		msg.ReplyMarkup = getBulkPreviewKeyboard(lang, token, len(upload.Devices))
+	} else {
+		msg.ReplyMarkup = getMainKeyboard(lang)
+	}
+	sender.Send(msg)
+}
+
+func handleBulkDecision(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	userID := callbackQuery.From.ID
+	chatID := callbackQuery.Message.Chat.ID
+	data := callbackQuery.Data
+
+	publish := strings.HasPrefix(data, "bulk_publish_")
+	var token int
+	fmt.Sscanf(strings.TrimPrefix(strings.TrimPrefix(data, "bulk_publish_"), "bulk_cancel_"), "%d", &token)
+
+	devices, ok := state.Uploads.Take(userID, token)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "bulk.expired"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+	if !publish {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "bulk.cancelled"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+	if !canBulkUpload(state.Config, userID) {
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "bulk.verified_only")))
+		return
+	}
+
+	usage, err := getListingUsage(state, userID, time.Now())
+	if err != nil {
+		state.Uploads.Restore(userID, token, devices)
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	if left := listingQuotaLeft(state.Config, userID, usage); left >= 0 && len(devices) > left {
+		// Пакет остается: после снятия старых объявлений его можно опубликовать той же кнопкой
+		state.Uploads.Restore(userID, token, devices)
+		msg := tgbotapi.NewMessage(chatID, T(lang, "bulk.quota", len(devices), left))
+		msg.ReplyMarkup = getBulkPreviewKeyboard(lang, token, len(devices))
+		sender.Send(msg)
+		return
+	}
+
+	added, err := state.AddDevices(devices)
+	if err != nil {
+		// Пакет возвращается, и публикацию можно повторить той же кнопкой
+		log.Printf("Не удалось опубликовать пакет объявлений пользователя %d: %v", userID, err)
+		state.Uploads.Restore(userID, token, devices)
+		msg := tgbotapi.NewMessage(chatID, T(lang, "bulk.publish_failed"))
+		msg.ReplyMarkup = getBulkPreviewKeyboard(lang, token, len(devices))
+		sender.Send(msg)
+		return
+	}
+
+	onModeration := 0
+	for _, device := range added {
+		if device.Status == DeviceStatusModeration {
+			onModeration++
+		}
+	}
+	if onModeration > 0 {
+		notifyModeratorsAboutBatch(sender, state, callbackQuery.From.FirstName, onModeration)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, T(lang, "bulk.published", len(added)-onModeration, onModeration))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+func handleLanguage(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "language.choose"))
+	msg.ReplyMarkup = getLanguageKeyboard()
+	sender.Send(msg)
+}
+
+func handleLanguageChoice(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState) {
+	lang := strings.TrimPrefix(callbackQuery.Data, "lang_")
+	if !isSupportedLanguage(lang) {
+		return
+	}
+
+	if err := state.SetUserLanguage(callbackQuery.From.ID, lang); err != nil {
+		sendStorageError(sender, callbackQuery.Message.Chat.ID, lang, err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(callbackQuery.Message.Chat.ID, T(lang, "language.changed"))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+func handleCurrency(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "currency.choose"))
+	msg.ReplyMarkup = getCurrencyKeyboard(state.Rates, "dcur_", T(lang, "currency.original"))
+	sender.Send(msg)
+}
+
+func handleCurrencyChoice(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	chatID := callbackQuery.Message.Chat.ID
+	currency := normalizeCurrency(strings.TrimPrefix(callbackQuery.Data, "dcur_"))
+
+	var text string
+	switch {
+	case currency == displayCurrencyNone:
+		currency = ""
+		text = T(lang, "currency.reset")
+	case state.Rates.Supports(currency):
+		text = T(lang, "currency.changed", currency)
+	default:
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "currency.unknown")))
+		return
+	}
+
+	user := state.GetUser(callbackQuery.From.ID)
+	user.DisplayCurrency = currency
+	if err := state.SaveUser(user); err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+func handleCity(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	user := state.GetUser(message.From.ID)
+	msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "city.choose"))
+	msg.ReplyMarkup = getCityKeyboard(lang, "ucity_", user.City, T(lang, "city.reset"))
+	sender.Send(msg)
+}
+
+func handleCityChoice(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	chatID := callbackQuery.Message.Chat.ID
+	user := state.GetUser(callbackQuery.From.ID)
+
+	var text string
+	code := strings.TrimPrefix(callbackQuery.Data, "ucity_")
+	if city, ok := findCity(code); ok {
+		user.City = city.Code
+		// Геопозиция из другого города больше не описывает покупателя. Точку вдали
+		// от городов списка оставляем: город для нее и выбирают вручную.
+		if nearest, ok := nearestCity(user.Location); !user.Location.IsZero() && ok && nearest.Code != city.Code {
+			user.Location = GeoPoint{}
+		}
+		text = T(lang, "city.changed", city.Name(lang))
+	} else {
+		user.City = ""
+		user.Location = GeoPoint{}
+		text = T(lang, "city.reset_done")
+	}
+	if err := state.SaveUser(user); err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+// handleLocation принимает геопозицию: на шаге города мастера она задает место
+// объявления, в остальное время запоминается как место покупателя. Если рядом
+// с точкой нет городов списка, геопозиция сохраняется, а город просят выбрать.
+func handleLocation(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	point := GeoPoint{Latitude: message.Location.Latitude, Longitude: message.Location.Longitude}
+	city, near := nearestCity(point)
+
+	if state.GetUserState(userID) == "waiting_device_city" {
+		state.SetWaitingInput(userID, "location", formatGeoPoint(point))
+		if !near {
+			msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "sell.city_far"))
+			msg.ReplyMarkup = getCityKeyboard(lang, "scity_", state.GetUser(userID).City, "")
+			sender.Send(msg)
+			return
+		}
+		state.SetWaitingInput(userID, "city", city.Code)
+		askDescription(sender, message.Chat.ID, userID, state, lang)
+		return
+	}
+
+	user := state.GetUser(userID)
+	user.City = city.Code
+	user.Location = point
+	if err := state.SaveUser(user); err != nil {
+		sendStorageError(sender, message.Chat.ID, lang, err)
+		return
+	}
+	if !near {
+		msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "location.far"))
+		msg.ReplyMarkup = getCityKeyboard(lang, "ucity_", "", "")
+		sender.Send(msg)
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "location.saved", city.Name(lang)))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+// handleSortedListing показывает объявления категории по цене, по расстоянию от
+// покупателя или только в его городе
+func handleSortedListing(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	chatID := callbackQuery.Message.Chat.ID
+	user := state.GetUser(callbackQuery.From.ID)
+
+	var mode, category string
+	for _, prefix := range []string{listingByPrice, listingNearby, listingInCity} {
+		if strings.HasPrefix(callbackQuery.Data, prefix) {
+			mode, category = prefix, strings.TrimPrefix(callbackQuery.Data, prefix)
+		}
+	}
+
+	from, located := buyerPoint(user)
+	if mode != listingByPrice && !located {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "location.unknown"))
+		msg.ReplyMarkup = getBackKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	var devices []Device
+	var err error
+	if category == sortAllDevices {
+		devices, err = state.GetDevices()
+	} else {
+		devices, err = state.GetDevicesByCategory(category)
+	}
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	if len(devices) == 0 {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "browse.empty"))
+		msg.ReplyMarkup = getBackKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	if mode == listingInCity {
+		city := user.City
+		if city == "" {
+			nearest, ok := nearestCity(from)
+			if !ok {
+				msg := tgbotapi.NewMessage(chatID, T(lang, "location.no_city"))
+				msg.ReplyMarkup = getCityKeyboard(lang, "ucity_", "", "")
+				sender.Send(msg)
+				return
+			}
+			city = nearest.Code
+		}
+		devices = filterDevicesByCity(devices, city)
+		if len(devices) == 0 {
+			msg := tgbotapi.NewMessage(chatID, T(lang, "browse.empty_city", cityName(lang, city)))
+			msg.ReplyMarkup = getBackKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+	}
+
+	header := TN(lang, "browse.sorted", len(devices))
+	switch mode {
+	case listingNearby:
+		sortDevicesByDistance(devices, from)
+		header = TN(lang, "browse.nearby", len(devices))
+	case listingInCity:
+		sortDevicesByPrice(devices, state.Rates)
+		header = TN(lang, "browse.in_city", len(devices))
+	default:
+		sortDevicesByPrice(devices, state.Rates)
+	}
+
+	sender.Send(tgbotapi.NewMessage(chatID, header))
+	for _, device := range devices {
+		text := formatDeviceForBuyer(lang, device, state.Rates, user.DisplayCurrency)
+		if mode == listingNearby {
+			text = formatDeviceNearBuyer(lang, device, state.Rates, user.DisplayCurrency, from)
+		}
+		deviceMsg := newHTMLMessage(chatID, text)
+		deviceMsg.ReplyMarkup = getDeviceCardKeyboard(lang, sender.BotUsername(), device)
+		sender.Send(deviceMsg)
+	}
+
+	backMsg := tgbotapi.NewMessage(chatID, T(lang, "browse.back"))
+	backMsg.ReplyMarkup = getBackKeyboard(lang)
+	sender.Send(backMsg)
+}
+
+// publishDevice сохраняет объявление, собранное мастером, после последнего шага
+func publishDevice(sender *Sender, chatID int64, from *tgbotapi.User, state *BotState, lang string) {
+	userID := from.ID
+	input := state.GetWaitingInput(userID)
+	price, _ := ParseMoney(input["price"])
+	currency := input["currency"]
+	if currency == "" {
+		currency = state.Rates.Base
+	}
+
+	device := Device{
+		Name:        input["name"],
+		Description: input["description"],
+		Price:       price,
+		Currency:    currency,
+		SellerID:    userID,
+		SellerName:  from.FirstName,
+		Contact:     input["contact"],
+		Category:    input["category"],
+		CreatedAt:   time.Now(),
+		Condition:   input["condition"],
+		Defects:     splitCodes(input["defects"]),
+		Kit:         splitCodes(input["kit"]),
+		IMEI:        input["imei"],
+		City:        input["city"],
+		Delivery:    splitCodes(input["delivery"]),
+	}
+	if containsString(device.Delivery, DeliveryShipping) {
+		device.ShippingCost, _ = parseShippingCost(input["shipping_cost"])
+	}
+	device.ContactVerified = contactVerified(state.GetUser(userID), device.Contact)
+	if location, ok := parseGeoPoint(input["location"]); ok {
+		device.Location = location
+	}
+	if warranty, ok := parseWarrantyDate(input["warranty"]); ok {
+		device.WarrantyUntil = warranty
+	}
+	for key, value := range input {
+		if name, ok := strings.CutPrefix(key, "attr:"); ok {
+			if device.Attributes == nil {
+				device.Attributes = make(map[string]string)
+			}
+			device.Attributes[name] = value
+		}
+	}
+
+	usage, err := getListingUsage(state, userID, device.CreatedAt)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	if quotaMessage := checkListingQuota(lang, state.Config, userID, usage); quotaMessage != "" {
+		state.ClearWaitingInput(userID)
+		state.SetUserState(userID, "")
+
+		msg := tgbotapi.NewMessage(chatID, quotaMessage)
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	device, err = state.AddDevice(device)
+	if err != nil {
+		// Введенные данные и шаг мастера сохраняются: повторная отправка контакта
+		// снова попробует сохранить объявление
+		log.Printf("Не удалось сохранить объявление пользователя %d: %v", userID, err)
+		sender.Send(tgbotapi.NewMessage(chatID, T(lang, "sell.save_failed")))
+		return
+	}
+	state.ClearWaitingInput(userID)
+	state.SetUserState(userID, "")
+
+	// Контакт запоминается и предлагается в следующем объявлении. Профиль читается
+	// заново: AddDevice мог только что создать его с именем продавца.
+	if seller := state.GetUser(userID); seller.Contact != device.Contact {
+		seller.Contact = device.Contact
+		if err := state.SaveUser(seller); err != nil {
+			log.Printf("Не удалось запомнить контакт пользователя %d: %v", userID, err)
+		}
+	}
+
+	if device.Status == DeviceStatusModeration {
+		notifyModerators(sender, state, device)
+
+		msg := tgbotapi.NewMessage(chatID, T(lang, "sell.moderation"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	msg := newHTMLMessage(chatID, formatDeviceAdded(lang, device))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+// askNextAttribute задает следующий вопрос о характеристиках из схемы выбранной
+// категории, а когда вопросы кончились, переходит к оценке состояния. Номер шага хранится
+// во вводе мастера, поэтому пропуск и повторный ввод не сбивают порядок.
+func askNextAttribute(sender *Sender, chatID, userID int64, state *BotState, lang, prefix string) {
+	def, ok := currentAttribute(state, userID)
+	if !ok {
+		state.SetUserState(userID, "waiting_device_condition")
+		msg := tgbotapi.NewMessage(chatID, prefix+T(lang, "sell.ask_condition"))
+		msg.ReplyMarkup = getConditionKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	state.SetUserState(userID, "waiting_device_attribute")
+	msg := tgbotapi.NewMessage(chatID, prefix+T(lang, "attr.ask."+def.Key)+"\n"+formatAttributeHint(lang, def))
+	msg.ReplyMarkup = getAttributeKeyboard(lang, def)
+	sender.Send(msg)
+}
+
+func currentAttribute(state *BotState, userID int64) (AttributeDef, bool) {
+	input := state.GetWaitingInput(userID)
+	schema := categorySchema(input["category"])
+	step, _ := strconv.Atoi(input["attr_step"])
+	if step >= len(schema) {
+		return AttributeDef{}, false
+	}
+	return schema[step], true
+}
+
+// recordAttribute запоминает ответ на текущий шаг; пустое значение — характеристика пропущена
+func recordAttribute(state *BotState, userID int64, def AttributeDef, value string) {
+	if value != "" {
+		if normalized, ok := normalizeAttributeValue(def, value); ok {
+			state.SetWaitingInput(userID, "attr:"+def.Key, normalized)
+		}
+	}
+	step, _ := strconv.Atoi(state.GetWaitingInput(userID)["attr_step"])
+	state.SetWaitingInput(userID, "attr_step", strconv.Itoa(step+1))
+}
+
+// handleConditionCallback обрабатывает кнопки шагов мастера после характеристик: оценку,
+// отметки дефектов, комплекта и способов получения, отказ от гарантии, пропуск IMEI,
+// выбор города и бесплатную доставку
+func handleConditionCallback(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	userID := callbackQuery.From.ID
+	chatID := callbackQuery.Message.Chat.ID
+	data := callbackQuery.Data
+	userState := state.GetUserState(userID)
+
+	switch {
+	case strings.HasPrefix(data, "cond_") && userState == "waiting_device_condition":
+		if grade, ok := normalizeCondition(strings.TrimPrefix(data, "cond_")); ok {
+			recordCondition(sender, chatID, userID, state, lang, grade)
+		}
+
+	case strings.HasPrefix(data, "defect_") && userState == "waiting_device_defects":
+		if data == "defect_done" {
+			askChecklist(sender, chatID, userID, state, lang, "waiting_device_kit")
+			return
+		}
+		toggleWizardChecklist(sender, callbackQuery, state, lang, strings.TrimPrefix(data, "defect_"))
+
+	case strings.HasPrefix(data, "kit_") && userState == "waiting_device_kit":
+		if data == "kit_done" {
+			state.SetUserState(userID, "waiting_device_warranty")
+			msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_warranty"))
+			msg.ReplyMarkup = getWarrantyKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		toggleWizardChecklist(sender, callbackQuery, state, lang, strings.TrimPrefix(data, "kit_"))
+
+	case data == "warranty_none" && userState == "waiting_device_warranty":
+		askIMEI(sender, chatID, userID, state, lang)
+
+	case data == "imei_skip" && userState == "waiting_device_imei":
+		askCity(sender, chatID, userID, state, lang)
+
+	case strings.HasPrefix(data, "scity_") && userState == "waiting_device_city":
+		if city, ok := findCity(strings.TrimPrefix(data, "scity_")); ok {
+			state.SetWaitingInput(userID, "city", city.Code)
+			askDescription(sender, chatID, userID, state, lang)
+		}
+
+	case strings.HasPrefix(data, "delivery_") && userState == "waiting_device_delivery":
+		if data != "delivery_done" {
+			toggleWizardChecklist(sender, callbackQuery, state, lang, strings.TrimPrefix(data, "delivery_"))
+			return
+		}
+		delivery := splitCodes(state.GetWaitingInput(userID)["delivery"])
+		if len(delivery) == 0 {
+			sender.Send(tgbotapi.NewMessage(chatID, T(lang, "sell.delivery_required")))
+			return
+		}
+		if containsString(delivery, DeliveryShipping) {
+			state.SetUserState(userID, "waiting_device_shipping_cost")
+			msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_shipping_cost"))
+			msg.ReplyMarkup = getShippingCostKeyboard(lang)
+			sender.Send(msg)
+			return
+		}
+		askContact(sender, chatID, userID, state, lang)
+
+	case data == "shipping_free" && userState == "waiting_device_shipping_cost":
+		state.SetWaitingInput(userID, "shipping_cost", "0")
+		askContact(sender, chatID, userID, state, lang)
+	}
+}
+
+// recordCondition запоминает оценку; у нового устройства дефектов не бывает,
+// поэтому шаг с дефектами пропускается
+func recordCondition(sender *Sender, chatID, userID int64, state *BotState, lang, grade string) {
+	state.SetWaitingInput(userID, "condition", grade)
+	if grade == "new" {
+		askChecklist(sender, chatID, userID, state, lang, "waiting_device_kit")
+		return
+	}
+	askChecklist(sender, chatID, userID, state, lang, "waiting_device_defects")
+}
+
+// wizardChecklists описывает шаги мастера с отметками: ключ ввода, пункты,
+// префикс названий и callback и вопрос
+var wizardChecklists = map[string]struct {
+	input, namePrefix, callbackPrefix, question string
+	options                                     []string
+}{
+	"waiting_device_defects":  {"defects", "defect.", "defect_", "sell.ask_defects", deviceDefects},
+	"waiting_device_kit":      {"kit", "kit.", "kit_", "sell.ask_kit", kitItems},
+	"waiting_device_delivery": {"delivery", "delivery.", "delivery_", "sell.ask_delivery", deliveryMethods},
+}
+
+func askChecklist(sender *Sender, chatID, userID int64, state *BotState, lang, step string) {
+	checklist := wizardChecklists[step]
+	selected := splitCodes(state.GetWaitingInput(userID)[checklist.input])
+
+	state.SetUserState(userID, step)
+	msg := tgbotapi.NewMessage(chatID, T(lang, checklist.question))
+	msg.ReplyMarkup = getChecklistKeyboard(lang, checklist.namePrefix, checklist.callbackPrefix, checklist.options, selected)
+	sender.Send(msg)
+}
+
+// toggleWizardChecklist переключает пункт и обновляет отметки в том же сообщении
+func toggleWizardChecklist(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang, code string) {
+	userID := callbackQuery.From.ID
+	checklist := wizardChecklists[state.GetUserState(userID)]
+	if !containsString(checklist.options, code) {
+		return
+	}
+
+	selected := toggleChecklist(checklist.options, splitCodes(state.GetWaitingInput(userID)[checklist.input]), code)
+	state.SetWaitingInput(userID, checklist.input, strings.Join(selected, ","))
+
+	keyboard := getChecklistKeyboard(lang, checklist.namePrefix, checklist.callbackPrefix, checklist.options, selected)
+	sender.Send(tgbotapi.NewEditMessageReplyMarkup(callbackQuery.Message.Chat.ID, callbackQuery.Message.MessageID, keyboard))
+}
+
+// askIMEI предлагает указать IMEI; для устройств без модуля связи шаг пропускается
+func askIMEI(sender *Sender, chatID, userID int64, state *BotState, lang string) {
+	if !categoryHasIMEI(state.GetWaitingInput(userID)["category"]) {
+		askCity(sender, chatID, userID, state, lang)
+		return
+	}
+
+	state.SetUserState(userID, "waiting_device_imei")
+	msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_imei"))
+	msg.ReplyMarkup = getIMEIKeyboard(lang)
+	sender.Send(msg)
+}
+
+func getIMEIKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.skip"), "imei_skip"),
+		),
+	)
+}
+
+// askCity спрашивает город устройства; город по умолчанию из /city стоит первым
+func askCity(sender *Sender, chatID, userID int64, state *BotState, lang string) {
+	state.SetUserState(userID, "waiting_device_city")
+	msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_city"))
+	msg.ReplyMarkup = getCityKeyboard(lang, "scity_", state.GetUser(userID).City, "")
+	sender.Send(msg)
+}
+
+func askDescription(sender *Sender, chatID, userID int64, state *BotState, lang string) {
+	state.SetUserState(userID, "waiting_device_description")
+	sender.Send(tgbotapi.NewMessage(chatID, T(lang, "sell.ask_description")))
+}
+
+func askContact(sender *Sender, chatID, userID int64, state *BotState, lang string) {
+	state.SetUserState(userID, "waiting_device_contact")
+	msg := tgbotapi.NewMessage(chatID, T(lang, "sell.ask_contact"))
+	msg.ReplyMarkup = getContactKeyboard(lang, state.GetUser(userID))
+	sender.Send(msg)
+}
+
+// acceptListingContact убирает клавиатуру выбора контакта, сообщает, подтвержден ли
+// контакт, и публикует объявление
+func acceptListingContact(sender *Sender, chatID int64, from *tgbotapi.User, state *BotState, lang, contact string) {
+	state.SetWaitingInput(from.ID, "contact", contact)
+
+	key := "sell.contact_accepted"
+	if contactVerified(state.GetUser(from.ID), contact) {
+		key = "sell.contact_verified"
+	}
+	msg := tgbotapi.NewMessage(chatID, T(lang, key, contact))
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	sender.Send(msg)
+
+	publishDevice(sender, chatID, from, state, lang)
+}
+
+// handleSharedContact принимает номер из кнопки «Поделиться номером». Подтвержденным
+// считается только собственный номер: чужую карточку контакта можно переслать,
+// но ее user_id не совпадет с отправителем.
+func handleSharedContact(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	userID := message.From.ID
+	if message.Contact.UserID != userID {
+		sender.Send(tgbotapi.NewMessage(message.Chat.ID, T(lang, "contact.not_own")))
+		return
+	}
+
+	user := state.GetUser(userID)
+	user.Phone = normalizePhone(message.Contact.PhoneNumber)
+	if err := state.SaveUser(user); err != nil {
+		sendStorageError(sender, message.Chat.ID, lang, err)
+		return
+	}
+
+	if state.GetUserState(userID) == "waiting_device_contact" {
+		acceptListingContact(sender, message.Chat.ID, message.From, state, lang, user.Phone)
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, T(lang, "contact.saved", user.Phone))
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	sender.Send(msg)
+}
+
+// handleSellCategory ведет продавца по дереву каталога: категория → бренд → модель.
+// Выбранная модель заменяет ввод названия вручную, а кнопка «Нет в списке» оставляет
+// текущий узел и просит ввести название.
+func handleSellCategory(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	userID := callbackQuery.From.ID
+	chatID := callbackQuery.Message.Chat.ID
+	pick := strings.HasPrefix(callbackQuery.Data, "catpick_")
+	code := strings.TrimPrefix(strings.TrimPrefix(callbackQuery.Data, "catpick_"), "cat_")
+
+	// Кнопка могла остаться от клавиатуры, показанной до того, как администратор выключил категорию
+	if (code != "" || pick) && !categoryList.Available(code) {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "category.unavailable"))
+		msg.ReplyMarkup = getCategoryKeyboard(lang, "")
+		sender.Send(msg)
+		return
+	}
+
+	if !pick && (code == "" || len(categoryList.Children(code)) > 0) {
+		text := T(lang, "sell.ask_category")
+		if code != "" {
+			text = T(lang, "sell.ask_subcategory", categoryDisplayName(lang, code))
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = getCategoryKeyboard(lang, code)
+		sender.Send(msg)
+		return
+	}
+
+	state.SetWaitingInput(userID, "category", code)
+	if name := categoryList.ModelName(lang, code); name != "" && !pick {
+		state.SetWaitingInput(userID, "name", name)
+		askNextAttribute(sender, chatID, userID, state, lang, T(lang, "sell.model_chosen", name)+"\n")
+		return
+	}
+
+	state.SetUserState(userID, "waiting_device_name")
+	sender.Send(tgbotapi.NewMessage(chatID, T(lang, "sell.ask_name")))
+}
+
+// handleBrowseCategory раскрывает узел каталога, у которого есть подкатегории, а для
+// конечного узла или кнопки «Все в разделе» показывает объявления всего поддерева
+func handleBrowseCategory(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	chatID := callbackQuery.Message.Chat.ID
+	all := strings.HasPrefix(callbackQuery.Data, "catall_")
+	categoryCode := strings.TrimPrefix(strings.TrimPrefix(callbackQuery.Data, "catall_"), "cat_")
+
+	if categoryCode == "" || (!all && len(categoryList.Children(categoryCode)) > 0) {
+		showCategoryBrowser(sender, chatID, state, lang, categoryCode)
+		return
+	}
+
+	devices, err := state.GetDevicesByCategory(categoryCode)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	if len(devices) == 0 {
+		sender.Send(newHTMLMessage(chatID, formatCategoryHeader(lang, categoryCode, 0)))
+		parent, _ := categoryList.Get(categoryCode)
+		showCategoryBrowser(sender, chatID, state, lang, parent.Parent)
+		return
+	}
+
+	displayCurrency := state.GetUser(callbackQuery.From.ID).DisplayCurrency
+	sender.Send(newHTMLMessage(chatID, formatCategoryHeader(lang, categoryCode, len(devices))))
+	for _, device := range devices {
+		deviceMsg := newHTMLMessage(chatID, formatDeviceForBuyer(lang, device, state.Rates, displayCurrency))
+		deviceMsg.ReplyMarkup = getDeviceCardKeyboard(lang, sender.BotUsername(), device)
+		sender.Send(deviceMsg)
+	}
+
+	backMsg := tgbotapi.NewMessage(chatID, T(lang, "browse.other_category"))
+	backMsg.ReplyMarkup = getListingKeyboard(lang, categoryCode)
+	sender.Send(backMsg)
+}
+
+// showCategoryBrowser показывает подкатегории parent с числом объявлений в каждой
+func showCategoryBrowser(sender *Sender, chatID int64, state *BotState, lang, parent string) {
+	devices, err := state.GetDevices()
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	text := T(lang, "browse.choose_category")
+	if parent != "" {
+		text = T(lang, "browse.choose_subcategory", categoryDisplayName(lang, parent))
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = getCategoriesKeyboard(lang, parent, categoryList.Counts(devices))
+	sender.Send(msg)
+}
+
+func handleHelp(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) {
+	helpText := T(lang, "help.text")
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, helpText)
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
+
+// getCategoryKeyboard показывает продавцу подкатегории parent; parent "" — корневые категории
+func getCategoryKeyboard(lang, parent string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	for _, category := range categoryList.Children(parent) {
+		button := tgbotapi.NewInlineKeyboardButtonData(category.Label(lang), "cat_"+category.Code)
+		row := []tgbotapi.InlineKeyboardButton{button}
+		rows = append(rows, row)
+	}
+
+	if parent != "" {
+		node, _ := categoryList.Get(parent)
+		notListedButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.not_listed"), "catpick_"+parent)
+		backButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.back"), "cat_"+node.Parent)
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{notListedButton})
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{backButton})
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// getCategoriesKeyboard показывает покупателю подкатегории parent с числом объявлений
+func getCategoriesKeyboard(lang, parent string, counts map[string]int) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	for _, category := range categoryList.Children(parent) {
+		label := fmt.Sprintf("%s (%d)", category.Label(lang), counts[category.Code])
+		button := tgbotapi.NewInlineKeyboardButtonData(label, "cat_"+category.Code)
+		row := []tgbotapi.InlineKeyboardButton{button}
+		rows = append(rows, row)
+	}
+
+	if parent == "" {
+		allButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.all_devices"), "browse_all_devices")
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{allButton})
+	} else {
+		node, _ := categoryList.Get(parent)
+		allButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.all_in_category", counts[parent]), "catall_"+parent)
+		upButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.back"), "cat_"+node.Parent)
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{allButton})
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{upButton})
+	}
+	backButton := tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.back_to_menu"), "back_to_main")
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{backButton})
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func getBackKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_categories"), "back_to_categories"),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_main"), "back_to_main"),
+		),
+	)
+}
+
+const (
+	sortAllDevices      = "all"
+	displayCurrencyNone = "NONE"
+)
+
+// Префиксы callback режимов просмотра категории; за префиксом идет код категории
+const (
+	listingByPrice = "sort_price_"
+	listingNearby  = "sort_near_"
+	listingInCity  = "in_city_"
+)
+
+func getListingKeyboard(lang, category string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.sort_price"), listingByPrice+category),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.sort_nearby"), listingNearby+category),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.in_my_city"), listingInCity+category),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_categories"), "back_to_categories"),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_main"), "back_to_main"),
+		),
+	)
+}
+
+// getCurrencyKeyboard строит клавиатуру доступных валют; noneLabel добавляет кнопку отказа от пересчета
+func getCurrencyKeyboard(rates *CurrencyRates, prefix, noneLabel string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+
+	for _, currency := range rates.Currencies() {
+		label := currency
+		if symbol, ok := CurrencySymbols[currency]; ok {
+			label = symbol + " " + currency
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, prefix+currency))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	if noneLabel != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(noneLabel, prefix+displayCurrencyNone),
+		))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func getMainMenuButton(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_main"), "back_to_main"),
+		),
+	)
+}
+
+func getMainKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.browse"), "browse_devices"),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.sell"), "sell_device"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.search"), "search_devices"),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.my_devices"), "my_devices"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.help"), "help"),
+		),
+	)
+}
+
+// handleStartPayload открывает то, на что ведет ссылка t.me/<бот>?start=<payload>:
+// listing_<id> — объявление, search_<запрос в base64> — поиск, seller_<id> — профиль
+// продавца. Возвращает false, если приветствие нужно показать.
+func handleStartPayload(sender *Sender, message *tgbotapi.Message, state *BotState, lang string) bool {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+	payload := message.CommandArguments()
+
+	if idText, ok := strings.CutPrefix(payload, deepLinkListingPrefix); ok {
+		deviceID, err := strconv.Atoi(idText)
+		if err != nil {
+			return false
+		}
+		showSharedListing(sender, chatID, userID, state, lang, deviceID)
+		return true
+	}
+	if strings.HasPrefix(payload, deepLinkQueryPrefix) || strings.HasPrefix(payload, deepLinkSearchPrefix) {
+		query, ok := parseSearchPayload(payload)
+		if !ok {
+			return false
+		}
+		runSearch(sender, chatID, userID, state, lang, query)
+		return true
+	}
+	if idText, ok := strings.CutPrefix(payload, deepLinkSellerPrefix); ok {
+		sellerID, err := strconv.ParseInt(idText, 10, 64)
+		if err != nil {
+			return false
+		}
+		showSellerProfile(sender, state, lang, chatID, 0, userID, sellerID, 0)
+		return true
+	}
+	return false
+}
+
+// showSharedListing показывает объявление по ссылке. Снятые с витрины объявления
+// (проданные, на модерации) по старым ссылкам не открываются.
+func showSharedListing(sender *Sender, chatID, userID int64, state *BotState, lang string, deviceID int) {
+	device, found, err := state.FindDeviceByID(deviceID)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	if !found || device.Status != DeviceStatusActive {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "device.not_found"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+
+	displayCurrency := state.GetUser(userID).DisplayCurrency
+	deviceMsg := newHTMLMessage(chatID, formatDeviceForBuyer(lang, device, state.Rates, displayCurrency))
+	deviceMsg.ReplyMarkup = getDeviceCardKeyboard(lang, sender.BotUsername(), device)
+	sender.Send(deviceMsg)
+
+	backMsg := tgbotapi.NewMessage(chatID, T(lang, "browse.back"))
+	backMsg.ReplyMarkup = getBackKeyboard(lang)
+	sender.Send(backMsg)
+}
+
+// getDeviceCardKeyboard — кнопки под карточкой объявления для покупателя. Кнопка
+// «Поделиться» открывает выбор чата со ссылкой на объявление; без имени бота
+// ссылку не построить, и кнопки нет.
+func getDeviceCardKeyboard(lang, botName string, device Device) tgbotapi.InlineKeyboardMarkup {
+	row := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.seller_profile"), fmt.Sprintf("seller_%d", device.SellerID)),
+	)
+	if botName != "" {
+		link := deepLink(botName, listingPayload(device.ID))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonURL(T(lang, "button.share"), shareLink(link, device.Name)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// getSearchKeyboard — главное меню под результатами поиска и ссылка на этот же поиск,
+// если запрос помещается в параметр start
+func getSearchKeyboard(lang, botName, query string) tgbotapi.InlineKeyboardMarkup {
+	keyboard := getMainKeyboard(lang)
+	payload, ok := searchPayload(query)
+	if botName == "" || !ok {
+		return keyboard
+	}
+	link := deepLink(botName, payload)
+	share := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonURL(T(lang, "button.share_search"), shareLink(link, strings.TrimSpace(query))),
+	)
+	keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{share}, keyboard.InlineKeyboard...)
+	return keyboard
+}
+
+func getDeviceActionsKeyboard(lang string, device Device) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if device.Status == DeviceStatusActive {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.mark_sold"), fmt.Sprintf("sold_device_%d", device.ID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.remove"), fmt.Sprintf("remove_device_%d", device.ID)),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// sendStorageError сообщает пользователю, что действие не выполнено из-за сбоя хранилища,
+// вместо того чтобы молча показать пустой список или ложное подтверждение
+func sendStorageError(sender *Sender, chatID int64, lang string, err error) {
+	log.Printf("Ошибка хранилища: %v", err)
+	msg := tgbotapi.NewMessage(chatID, T(lang, "error.storage"))
+	msg.ReplyMarkup = getMainKeyboard(lang)
+	sender.Send(msg)
+}
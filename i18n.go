@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	LangRU = "ru"
+	LangEN = "en"
+
+	defaultLanguage = LangRU
+)
+
+var SupportedLanguages = []string{LangRU, LangEN}
+
+var LanguageNames = map[string]string{
+	LangRU: "🇷🇺 Русский",
+	LangEN: "🇬🇧 English",
+}
+
+var catalogs = map[string]map[string]string{
+	LangRU: catalogRU,
+	LangEN: catalogEN,
+}
+
+// T возвращает строку из каталога языка lang. Если ключа нет в каталоге,
+// используется русский вариант, а при его отсутствии — сам ключ.
+func T(lang, key string, args ...interface{}) string {
+	text, ok := catalogs[lang][key]
+	if !ok {
+		text, ok = catalogs[defaultLanguage][key]
+	}
+	if !ok {
+		log.Printf("Нет перевода для ключа %s", key)
+		text = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// TN выбирает форму множественного числа для n: ключ каталога дополняется
+// суффиксом .one, .few, .many (русский) или .one, .other (английский)
+func TN(lang, key string, n int, args ...interface{}) string {
+	return T(lang, key+"."+pluralForm(lang, n), append([]interface{}{n}, args...)...)
+}
+
+func pluralForm(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case LangEN:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	default:
+		mod10, mod100 := n%10, n%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	}
+}
+
+func isSupportedLanguage(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// detectLanguage сопоставляет language_code из Telegram с поддерживаемым языком.
+// Русский интерфейс получают и пользователи с близкими языками.
+func detectLanguage(languageCode string) string {
+	code := strings.ToLower(languageCode)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+
+	switch code {
+	case "":
+		return defaultLanguage
+	case "ru", "uk", "be", "kk":
+		return LangRU
+	}
+	if isSupportedLanguage(code) {
+		return code
+	}
+	return LangEN
+}
+
+// userLanguage возвращает выбранный пользователем язык, а если он не выбран — язык клиента Telegram
+func userLanguage(state *BotState, user *tgbotapi.User) string {
+	if lang := state.GetUserLanguage(user.ID); isSupportedLanguage(lang) {
+		return lang
+	}
+	return detectLanguage(user.LanguageCode)
+}
+
+// recipientLanguage используется для уведомлений, когда клиент получателя неизвестен
+func recipientLanguage(state *BotState, userID int64) string {
+	if lang := state.GetUserLanguage(userID); isSupportedLanguage(lang) {
+		return lang
+	}
+	return defaultLanguage
+}
+
+func getLanguageKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, lang := range SupportedLanguages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(LanguageNames[lang], "lang_"+lang))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
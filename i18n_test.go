@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPluralForm(t *testing.T) {
+	for _, c := range []struct {
+		n      int
+		ru, en string
+	}{
+		{0, "many", "other"},
+		{1, "one", "one"},
+		{2, "few", "other"},
+		{4, "few", "other"},
+		{5, "many", "other"},
+		{11, "many", "other"},
+		{12, "many", "other"},
+		{14, "many", "other"},
+		{21, "one", "other"},
+		{22, "few", "other"},
+		{25, "many", "other"},
+		{111, "many", "other"},
+		{101, "one", "other"},
+		{-1, "one", "one"},
+	} {
+		if got := pluralForm(LangRU, c.n); got != c.ru {
+			t.Errorf("pluralForm(ru, %d) = %s, ожидалось %s", c.n, got, c.ru)
+		}
+		if got := pluralForm(LangEN, c.n); got != c.en {
+			t.Errorf("pluralForm(en, %d) = %s, ожидалось %s", c.n, got, c.en)
+		}
+	}
+
+	for n, want := range map[int]string{1: "1 активное", 2: "2 активных объявления", 5: "5 активных объявлений", 11: "11 активных объявлений", 21: "21 активное"} {
+		if got := TN(LangRU, "quota.active", n); !strings.Contains(got, want) {
+			t.Errorf("TN(ru, %d) = %q", n, got)
+		}
+	}
+}
+
+func TestTranslationFallback(t *testing.T) {
+	if got := T("de", "device.not_found"); got != catalogRU["device.not_found"] {
+		t.Errorf("неизвестный язык: %q", got)
+	}
+	if got := T(LangEN, "no.such.key"); got != "no.such.key" {
+		t.Errorf("неизвестный ключ: %q", got)
+	}
+	for code, want := range map[string]string{"": LangRU, "ru": LangRU, "uk-UA": LangRU, "be": LangRU, "en-US": LangEN, "de": LangEN, "EN_gb": LangEN} {
+		if got := detectLanguage(code); got != want {
+			t.Errorf("detectLanguage(%q) = %s, ожидалось %s", code, got, want)
+		}
+	}
+}
+
+// Каталоги должны совпадать по ключам с точностью до форм множественного числа
+func TestCatalogsMatch(t *testing.T) {
+	pluralSuffixes := map[string][]string{LangRU: {"one", "few", "many"}, LangEN: {"one", "other"}}
+	baseKeys := func(lang string) map[string]bool {
+		keys := make(map[string]bool)
+		for key := range catalogs[lang] {
+			base := key
+			for _, suffix := range pluralSuffixes[lang] {
+				// Ключ вроде attr.color.other — значение, а не форма множественного числа
+				if trimmed, ok := strings.CutSuffix(key, "."+suffix); ok && catalogs[lang][trimmed+".one"] != "" {
+					base = trimmed + ".#"
+				}
+			}
+			keys[base] = true
+		}
+		return keys
+	}
+
+	ru, en := baseKeys(LangRU), baseKeys(LangEN)
+	for key := range ru {
+		if !en[key] {
+			t.Errorf("ключа %s нет в английском каталоге", key)
+		}
+	}
+	for key := range en {
+		if !ru[key] {
+			t.Errorf("ключа %s нет в русском каталоге", key)
+		}
+	}
+	for lang, suffixes := range pluralSuffixes {
+		for key := range baseKeys(lang) {
+			base, plural := strings.CutSuffix(key, ".#")
+			if !plural {
+				continue
+			}
+			for _, suffix := range suffixes {
+				if _, ok := catalogs[lang][base+"."+suffix]; !ok {
+					t.Errorf("нет формы %s.%s (%s)", base, suffix, lang)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IMEI хранится вместе с объявлением, но покупателям не показывается: в карточке
+// есть только отметка «IMEI проверен», а сам номер видят продавец и модераторы.
+const (
+	// Номер не повторяется в других объявлениях и не числится в розыске
+	IMEIStatusVerified = "verified"
+	// Номер в розыске или уже использован — объявление уходит на модерацию
+	IMEIStatusFlagged = "flagged"
+	// Сервис проверки не ответил; отметка не ставится, объявление публикуется
+	IMEIStatusUnchecked = "unchecked"
+)
+
+// IMEI спрашивается только для устройств с модулем связи
+var imeiCategories = map[string]bool{
+	CategorySmartphone: true,
+	CategoryTablet:     true,
+	CategorySmartwatch: true,
+}
+
+func categoryHasIMEI(category string) bool {
+	path := categoryList.Path(category)
+	return len(path) > 0 && imeiCategories[path[0].Code]
+}
+
+// normalizeIMEI убирает пробелы, дефисы и косые черты, которыми номер делят на группы,
+// и проверяет длину и контрольную цифру по алгоритму Луна
+func normalizeIMEI(input string) (string, bool) {
+	imei := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '/':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(input))
+	if len(imei) != 15 || !luhnValid(imei) {
+		return "", false
+	}
+	return imei, true
+}
+
+func luhnValid(number string) bool {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		// Каждая вторая цифра справа удваивается
+		if (len(number)-i)%2 == 0 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// IMEIChecker проверяет номер по базе украденных и потерянных устройств. Реализацию
+// для внешнего сервиса можно подключить через ContentPipeline.SetIMEIChecker.
+type IMEIChecker interface {
+	Stolen(imei string) (bool, error)
+}
+
+// fileIMEIChecker — локальная замена сервиса: текстовый файл с номерами по одному
+// на строку, строки с # — комментарии. Файл перечитывается после изменения, а его
+// отсутствие означает пустой список.
+type fileIMEIChecker struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	stolen  map[string]bool
+}
+
+func newFileIMEIChecker(path string) *fileIMEIChecker {
+	return &fileIMEIChecker{path: path}
+}
+
+func (c *fileIMEIChecker) Stolen(imei string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if c.stolen == nil || !info.ModTime().Equal(c.modTime) {
+		stolen, err := readIMEIList(c.path)
+		if err != nil {
+			return false, err
+		}
+		c.stolen = stolen
+		c.modTime = info.ModTime()
+	}
+	return c.stolen[imei], nil
+}
+
+func readIMEIList(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		imei, ok := normalizeIMEI(text)
+		if !ok {
+			log.Printf("%s:%d: некорректный IMEI %q пропущен", path, line, text)
+			continue
+		}
+		list[imei] = true
+	}
+	return list, scanner.Err()
+}
+
+// devicesWithIMEI выбирает объявления с тем же IMEI в любом статусе, кроме отклоненного
+func devicesWithIMEI(devices []Device, imei string) []Device {
+	if imei == "" {
+		return nil
+	}
+	var same []Device
+	for _, device := range devices {
+		if device.IMEI == imei && device.Status != DeviceStatusRejected {
+			same = append(same, device)
+		}
+	}
+	return same
+}
+
+// verifyIMEI проверяет номер объявления; непустая причина отправляет его на модерацию
+func verifyIMEI(checker IMEIChecker, device Device, existing []Device) (string, string) {
+	for _, other := range existing {
+		if other.IMEI != device.IMEI || (device.ID != 0 && other.ID == device.ID) {
+			continue
+		}
+		// У строк того же пакета, еще не сохраненных в базу, номера нет
+		if other.ID == 0 {
+			return IMEIStatusFlagged, "IMEI повторяется в загруженном файле"
+		}
+		return IMEIStatusFlagged, fmt.Sprintf("IMEI совпадает с объявлением #%d", other.ID)
+	}
+
+	if checker == nil {
+		return IMEIStatusVerified, ""
+	}
+	stolen, err := checker.Stolen(device.IMEI)
+	if err != nil {
+		log.Printf("Не удалось проверить IMEI объявления «%s»: %v", device.Name, err)
+		return IMEIStatusUnchecked, ""
+	}
+	if stolen {
+		return IMEIStatusFlagged, "IMEI числится в базе украденных устройств"
+	}
+	return IMEIStatusVerified, ""
+}
@@ -0,0 +1,42 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceIMEIRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	id, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: "iphone_13", Status: DeviceStatusActive, IMEI: "490154203237518", IMEIStatus: IMEIStatusVerified})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+	device, _, err := db.GetDeviceByID(id)
+	if err != nil || device.IMEI != "490154203237518" || device.IMEIStatus != IMEIStatusVerified {
+		t.Fatalf("IMEI после чтения: %+v, %v", device, err)
+	}
+
+	// Повтор ищется и среди объявлений на модерации и проданных, но не среди отклоненных
+	for _, status := range []string{DeviceStatusModeration, DeviceStatusSold, DeviceStatusRejected} {
+		if _, err := db.SaveDevice(Device{Name: "iPhone 13", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+			Category: "iphone_13", Status: status, IMEI: "356938035643809"}); err != nil {
+			t.Fatalf("SaveDevice(%s): %v", status, err)
+		}
+	}
+	same, err := db.FindDevicesByIMEI("356938035643809")
+	if err != nil || len(same) != 2 {
+		t.Fatalf("FindDevicesByIMEI = %+v, %v", same, err)
+	}
+	for _, other := range same {
+		if other.Status == DeviceStatusRejected {
+			t.Errorf("найдено отклоненное объявление: %+v", other)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeIMEI(t *testing.T) {
+	cases := map[string]string{
+		"490154203237518":      "490154203237518",
+		"49-015420-323751-8":   "490154203237518",
+		" 35 209900 176148 1 ": "352099001761481",
+		"490154203237517":      "",
+		"49015420323751":       "",
+		"49015420323751x":      "",
+	}
+	for input, want := range cases {
+		got, ok := normalizeIMEI(input)
+		if got != want || ok != (want != "") {
+			t.Errorf("normalizeIMEI(%q) = %q, %v; ожидался %q", input, got, ok, want)
+		}
+	}
+}
+
+type failingIMEIChecker struct{}
+
+func (failingIMEIChecker) Stolen(string) (bool, error) {
+	return false, errors.New("сервис недоступен")
+}
+
+func TestReviewIMEI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stolen_imei.txt")
+	if err := os.WriteFile(path, []byte("# список для теста\n35-209900-176148-1\nне номер\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pipeline := &ContentPipeline{}
+	pipeline.SetIMEIChecker(newFileIMEIChecker(path))
+
+	existing := []Device{{ID: 7, IMEI: "490154203237518", Status: DeviceStatusActive}}
+
+	clean := pipeline.Review(Device{IMEI: "356938035643809"}, nil, existing)
+	if clean.Status != DeviceStatusActive || clean.IMEIStatus != IMEIStatusVerified {
+		t.Errorf("чистый IMEI: %+v", clean)
+	}
+	reused := pipeline.Review(Device{IMEI: "490154203237518"}, nil, existing)
+	if reused.Status != DeviceStatusModeration || reused.IMEIStatus != IMEIStatusFlagged || reused.ModerationNote != "IMEI совпадает с объявлением #7" {
+		t.Errorf("повтор IMEI: %+v", reused)
+	}
+	stolen := pipeline.Review(Device{IMEI: "352099001761481"}, nil, existing)
+	if stolen.Status != DeviceStatusModeration || stolen.IMEIStatus != IMEIStatusFlagged {
+		t.Errorf("IMEI из списка украденных: %+v", stolen)
+	}
+	if none := pipeline.Review(Device{}, nil, existing); none.IMEIStatus != "" {
+		t.Errorf("объявление без IMEI: %+v", none)
+	}
+
+	pipeline.SetIMEIChecker(failingIMEIChecker{})
+	unchecked := pipeline.Review(Device{IMEI: "356938035643809"}, nil, existing)
+	if unchecked.Status != DeviceStatusActive || unchecked.IMEIStatus != IMEIStatusUnchecked {
+		t.Errorf("сервис проверки недоступен: %+v", unchecked)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	RoleUser     = "user"
+	RoleVerified = "verified"
+	RoleAdmin    = "admin"
+)
+
+// Нулевое значение любого лимита означает отсутствие ограничения
+type RoleLimits struct {
+	UpdatesPerMinute  int `json:"updates_per_minute"`
+	UpdatesBurst      int `json:"updates_burst"`
+	ListingsPerDay    int `json:"listings_per_day"`
+	MaxActiveListings int `json:"max_active_listings"`
+}
+
+func DefaultRoleLimits() map[string]RoleLimits {
+	return map[string]RoleLimits{
+		RoleUser: {
+			UpdatesPerMinute:  30,
+			UpdatesBurst:      10,
+			ListingsPerDay:    5,
+			MaxActiveListings: 20,
+		},
+		RoleVerified: {
+			UpdatesPerMinute:  60,
+			UpdatesBurst:      20,
+			ListingsPerDay:    50,
+			MaxActiveListings: 200,
+		},
+		RoleAdmin: {},
+	}
+}
+
+func (c Config) RoleOf(userID int64) string {
+	if c.IsAdmin(userID) {
+		return RoleAdmin
+	}
+	for _, id := range c.VerifiedSellerIDs {
+		if id == userID {
+			return RoleVerified
+		}
+	}
+	return RoleUser
+}
+
+func (c Config) LimitsFor(userID int64) RoleLimits {
+	if limits, ok := c.Limits[c.RoleOf(userID)]; ok {
+		return limits
+	}
+	return c.Limits[RoleUser]
+}
+
+// listingUsage — сколько объявлений продавца сейчас опубликовано или на модерации
+// и сколько он разместил за последние сутки, включая уже удаленные
+type listingUsage struct {
+	Active       int
+	CreatedToday int
+}
+
+// getListingUsage собирает данные для проверки лимитов. Размещения считаются по
+// журналу, а не по текущим объявлениям: иначе удаление объявления освобождало бы
+// место в суточном лимите.
+func getListingUsage(state *BotState, userID int64, now time.Time) (listingUsage, error) {
+	devices, err := state.GetUserDevices(userID)
+	if err != nil {
+		return listingUsage{}, err
+	}
+	createdToday, err := state.CountPostingsSince(userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return listingUsage{}, err
+	}
+
+	usage := listingUsage{CreatedToday: createdToday}
+	for _, device := range devices {
+		if device.Status == DeviceStatusActive || device.Status == DeviceStatusModeration {
+			usage.Active++
+		}
+	}
+	return usage, nil
+}
+
+// checkListingQuota возвращает текст для пользователя, если он исчерпал лимит объявлений,
+// и пустую строку, если новое объявление разместить можно
+func checkListingQuota(lang string, config Config, userID int64, usage listingUsage) string {
+	limits := config.LimitsFor(userID)
+
+	if limits.MaxActiveListings > 0 && usage.Active >= limits.MaxActiveListings {
+		return TN(lang, "quota.active", usage.Active)
+	}
+	if limits.ListingsPerDay > 0 && usage.CreatedToday >= limits.ListingsPerDay {
+		return TN(lang, "quota.daily", usage.CreatedToday)
+	}
+	return ""
+}
+
+// listingQuotaLeft возвращает, сколько объявлений пользователь может разместить сейчас,
+// с учетом обоих лимитов; -1 означает отсутствие ограничений
+func listingQuotaLeft(config Config, userID int64, usage listingUsage) int {
+	limits := config.LimitsFor(userID)
+
+	left := -1
+	if limits.MaxActiveListings > 0 {
+		left = max(limits.MaxActiveListings-usage.Active, 0)
+	}
+	if limits.ListingsPerDay > 0 {
+		daily := max(limits.ListingsPerDay-usage.CreatedToday, 0)
+		if left < 0 || daily < left {
+			left = daily
+		}
+	}
+	return left
+}
@@ -0,0 +1,40 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Удаление объявления не освобождает место в суточном лимите
+func TestDailyQuotaSurvivesRemoval(t *testing.T) {
+	state := newTestBotState(t)
+	state.Config.Limits[RoleUser] = RoleLimits{ListingsPerDay: 2}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		device, err := state.AddDevice(Device{Name: "iPhone", SellerID: 1, SellerName: "Иван", Price: 100, Currency: CurrencyRUB,
+			Category: CategorySmartphone, CreatedAt: now})
+		if err != nil {
+			t.Fatalf("AddDevice: %v", err)
+		}
+		if _, err := state.RemoveDevice(device.ID); err != nil {
+			t.Fatalf("RemoveDevice: %v", err)
+		}
+	}
+
+	usage, err := getListingUsage(state, 1, now)
+	if err != nil || usage.Active != 0 || usage.CreatedToday != 2 {
+		t.Fatalf("getListingUsage = %+v, %v", usage, err)
+	}
+	if checkListingQuota(LangRU, state.Config, 1, usage) == "" || listingQuotaLeft(state.Config, 1, usage) != 0 {
+		t.Error("после удаления объявлений лимит снова доступен")
+	}
+
+	// Через сутки размещения перестают учитываться
+	if usage, err := getListingUsage(state, 1, now.Add(25*time.Hour)); err != nil || usage.CreatedToday != 0 {
+		t.Errorf("через сутки: %+v, %v", usage, err)
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+var catalogEN = map[string]string{
+	"flood.callback": "Too many requests, please wait a moment.",
+	"flood.message":  "You are sending messages too fast. Please wait a minute and try again.",
+
+	"command.unknown": "Unknown command. Use /help for help.",
+
+	"menu.choose_action": "Choose an action:",
+	"menu.main":          "Main menu:",
+
+	"sell.ask_name":          "Enter the device name:",
+	"sell.ask_description":   "Enter the device description:",
+	"sell.ask_price":         "Enter the device price:",
+	"sell.invalid_price":     "Could not read the price. Enter a positive number, for example 15000 or 14999.90:",
+	"sell.ask_currency":      "Choose the price currency:",
+	"sell.ask_contact":       "Enter your contact details or press «Share phone number» — a number from Telegram gets a «verified» badge:",
+	"sell.contact_accepted":  "Listing contact: %s",
+	"sell.contact_verified":  "Listing contact: %s ✅ number verified by Telegram",
+	"sell.ask_category":      "Choose the device category:",
+	"sell.ask_subcategory":   "%s — choose the brand or model:",
+	"sell.model_chosen":      "Model: %s",
+	"sell.moderation":        "Your listing has been sent to moderation and will be published after review.",
+	"sell.save_failed":       "Could not save the listing: storage is temporarily unavailable. Your input is kept — send the contact again to retry.",
+	"sell.ask_condition":     "Rate the device condition:\n• New — never used, factory sealed\n• Refurbished — repaired by the manufacturer or a service center\n• Like new — no visible signs of use\n• Good — light wear, everything works\n• Fair — noticeable wear or minor faults\n• For parts — does not turn on or needs serious repair",
+	"sell.invalid_condition": "Choose the condition with a button:",
+	"sell.ask_defects":       "Mark any defects and press «Done»:",
+	"sell.ask_kit":           "What is included? Mark the items and press «Done»:",
+	"sell.ask_warranty":      "When does the warranty end? Enter a date as DD.MM.YYYY or press «No warranty».",
+	"sell.invalid_warranty":  "Could not recognize the date. Enter the warranty end date as DD.MM.YYYY, not earlier than today, or press «No warranty».",
+	"sell.ask_imei":          "Enter the device IMEI (15 digits, dial *#06# to see it). Buyers will not see the number, and the listing will get an «IMEI verified» badge. Press «Skip» if you prefer not to share it.",
+	"sell.invalid_imei":      "This does not look like an IMEI: it needs 15 digits with a valid check digit. Check the number or press «Skip».",
+	"sell.ask_city":          "Which city is the device in? Choose a city or share your location via 📎 — buyers will only see the city and the distance.",
+	"sell.invalid_city":      "This city is not on the list. Choose the nearest city with a button or share your location via 📎.",
+	"sell.city_far":          "There are no cities from the list near this point. Choose the nearest city with a button — the exact location will be kept too.",
+	"sell.ask_delivery":      "How can the buyer get the device? Mark every option that suits you and press «Done»:",
+	"sell.delivery_required": "Mark at least one delivery option.",
+	"sell.ask_shipping_cost": "How much does shipping by post or a delivery company cost? Enter the amount in the listing currency or press «Free».",
+	"sell.invalid_shipping":  "Enter the shipping cost as a number, for example 500, or press «Free».",
+
+	"attr.storage_gb":     "Storage",
+	"attr.ram_gb":         "RAM",
+	"attr.color":          "Color",
+	"attr.battery_health": "Battery health",
+	"attr.screen_size":    "Screen size",
+	"attr.sim_type":       "SIM",
+	"attr.strap_size":     "Strap size",
+
+	"attr.ask.storage_gb":     "How much storage does it have?",
+	"attr.ask.ram_gb":         "How much RAM does it have?",
+	"attr.ask.color":          "What color is the device?",
+	"attr.ask.battery_health": "What is the battery health in percent?",
+	"attr.ask.screen_size":    "What is the screen size in inches?",
+	"attr.ask.sim_type":       "Which SIM cards are supported?",
+	"attr.ask.strap_size":     "What is the strap size?",
+	"attr.hint.number":        "Enter a number from %s to %s or press «Skip».",
+	"attr.hint.choice":        "Choose an option or press «Skip».",
+	"attr.invalid":            "Could not recognize the value.",
+
+	"attr.unit.gb":      " GB",
+	"attr.unit.percent": "%",
+	"attr.unit.inch":    "″",
+
+	"attr.color.black":  "Black",
+	"attr.color.white":  "White",
+	"attr.color.silver": "Silver",
+	"attr.color.gold":   "Gold",
+	"attr.color.blue":   "Blue",
+	"attr.color.red":    "Red",
+	"attr.color.green":  "Green",
+	"attr.color.purple": "Purple",
+	"attr.color.pink":   "Pink",
+	"attr.color.other":  "Other",
+
+	"attr.sim_type.nano":      "nano-SIM",
+	"attr.sim_type.dual_nano": "2 × nano-SIM",
+	"attr.sim_type.nano_esim": "nano-SIM + eSIM",
+	"attr.sim_type.esim":      "eSIM only",
+	"attr.sim_type.none":      "No SIM",
+
+	"attr.strap_size.xs": "XS",
+	"attr.strap_size.s":  "S",
+	"attr.strap_size.m":  "M",
+	"attr.strap_size.l":  "L",
+	"attr.strap_size.xl": "XL",
+
+	"condition.new":         "New",
+	"condition.refurbished": "Refurbished",
+	"condition.like_new":    "Like new",
+	"condition.good":        "Good",
+	"condition.fair":        "Fair",
+	"condition.for_parts":   "For parts",
+
+	"defect.cracked_screen": "Cracked screen",
+	"defect.scratches":      "Scratches",
+	"defect.dents":          "Chips or dents on the body",
+	"defect.dead_pixels":    "Dead pixels",
+	"defect.battery_worn":   "Battery drains quickly",
+	"defect.camera":         "Camera not working",
+	"defect.buttons":        "Faulty buttons",
+	"defect.biometrics":     "Face ID or fingerprint not working",
+	"defect.water_damage":   "Water damage",
+
+	"kit.box":     "Box",
+	"kit.charger": "Charger",
+	"kit.cable":   "Cable",
+	"kit.receipt": "Receipt",
+
+	"delivery.pickup":   "Pickup",
+	"delivery.meetup":   "Meetup",
+	"delivery.courier":  "Courier",
+	"delivery.shipping": "Shipping",
+	"delivery.free":     "free",
+
+	"error.storage": "Could not complete the action: storage is temporarily unavailable. Please try again in a minute.",
+
+	"browse.choose_category":    "Choose a category:",
+	"browse.choose_subcategory": "%s — choose a section:",
+	"browse.other_category":     "Choose another category or return to the main menu:",
+	"browse.empty":              "There are no devices available right now.",
+	"browse.header.one":         "%d device available:",
+	"browse.header.other":       "%d devices available:",
+	"browse.sorted.one":         "%d device, cheapest first:",
+	"browse.sorted.other":       "%d devices, cheapest first:",
+	"browse.nearby.one":         "%d device, nearest first:",
+	"browse.nearby.other":       "%d devices, nearest first:",
+	"browse.in_city.one":        "%d device in your city, cheapest first:",
+	"browse.in_city.other":      "%d devices in your city, cheapest first:",
+	"browse.empty_city":         "There are no such devices in %s right now.",
+	"browse.back":               "Return to categories or the main menu:",
+
+	"category.unknown":     "Not specified",
+	"category.unavailable": "This category is no longer available, please choose another one:",
+
+	"search.ask":         "Enter a search query (name or description). You can add attribute filters, for example: storage:128 battery>=85 condition>=good warranty:yes city:moscow delivery:shipping price<=300",
+	"search.found.one":   "Found %d device",
+	"search.found.other": "Found %d devices",
+
+	"my.empty":        "You have no listings yet.",
+	"my.header.one":   "You have %d listing:",
+	"my.header.other": "You have %d listings:",
+	"my.back":         "Return to the main menu:",
+
+	"device.not_found":     "Device not found.",
+	"device.not_owner":     "You cannot change another user's listing.",
+	"device.removed":       "Listing removed.",
+	"device.remove_failed": "Failed to remove the listing.",
+	"device.sold":          "The listing is marked as sold and no longer shown to buyers.",
+	"device.not_active":    "Only a published listing can be marked as sold. It may still be under moderation, already sold or removed.",
+
+	"status.moderation": "⏳ Under moderation",
+	"status.rejected":   "🚫 Rejected by a moderator",
+	"status.sold":       "✅ Sold",
+
+	"quota.active.one":   "You already have %d active listing, which is the maximum. Remove outdated listings to post a new one.",
+	"quota.active.other": "You already have %d active listings, which is the maximum. Remove outdated listings to post a new one.",
+	"quota.daily.one":    "You have posted %d listing in the last 24 hours, which is the maximum. Please try again later.",
+	"quota.daily.other":  "You have posted %d listings in the last 24 hours, which is the maximum. Please try again later.",
+
+	"moderation.admin_only_command": "This command is available to moderators only.",
+	"moderation.admin_only_action":  "This action is available to moderators only.",
+	"moderation.empty":              "No listings are waiting for moderation.",
+	"moderation.header.one":         "%d listing under moderation:",
+	"moderation.header.other":       "%d listings under moderation:",
+	"moderation.batch.one":          "%d listing from %s's bulk upload awaits moderation: /moderation",
+	"moderation.batch.other":        "%d listings from %s's bulk upload await moderation: /moderation",
+	"moderation.approved":           "Listing #%d published.",
+	"moderation.rejected":           "Listing #%d rejected.",
+	"moderation.already_handled":    "Listing #%d is no longer under moderation: it has already been handled.",
+
+	"backup.done":        "Database backup: %s",
+	"backup.failed":      "Could not create a backup, see the bot log for details.",
+	"backup.too_large":   "The backup was saved on the server to %s but is too large to send via Telegram.",
+	"backup.unsupported": "Backups from the bot are available only for the SQLite database.",
+
+	"export.usage": "Usage: /export json or /export csv",
+	"export.done":  "Exported users: %d, listings: %d",
+
+	"categories.header":   "Catalog categories (order, emoji, code, names):",
+	"categories.item":     "%d. %s %s — %s %s",
+	"categories.enabled":  "✅",
+	"categories.disabled": "🚫 hidden",
+
+	"category.usage": `Editing categories:
+/category set <code> <emoji or -> <Название> | <Name> — create or rename
+/category parent <code> <parent code or -> — move to another section
+/category order <code> <number> — position in the list
+/category off <code> — hide from keyboards, listings stay
+/category on <code> — bring the category back`,
+	"category.error.bad_code":     "Invalid code %q: use lowercase Latin letters, digits and \"_\", up to 32 characters.",
+	"category.error.no_name":      "Please specify the category name.",
+	"category.error.not_found":    "Category %s not found. Category list: /categories",
+	"category.error.bad_order":    "The order must be an integer, not %q.",
+	"category.error.last_enabled": "The last visible category cannot be hidden.",
+	"category.error.cycle":        "A category cannot be moved inside itself.",
+
+	"import.ask_file":        "Send a JSON file (as produced by /export) or a CSV file with users or listings.",
+	"import.dry_run_note":    "Dry run: the file will be checked but no changes will be saved.",
+	"import.cancelled":       "Import cancelled.",
+	"import.too_large":       "The file is too large: Telegram lets bots download files up to 20 MB.",
+	"import.download_failed": "Could not download the file, please try again.",
+	"import.bad_file":        "Could not read the file: %v",
+	"import.failed":          "Import failed, no data was changed. See the bot log for details.",
+
+	"import.report.dry_run":     "Dry run, nothing saved:",
+	"import.report.summary":     "Users added: %d, already present: %d\nListings added: %d, duplicates skipped: %d\nErrors: %d",
+	"import.report.error":       "%s, record %d: %s",
+	"import.report.more_errors": "…and %d more errors",
+	"import.section.users":      "Users",
+	"import.section.devices":    "Listings",
+
+	"import.error.bad_id":         "invalid ID",
+	"import.error.duplicate_user": "user %d is repeated in the file",
+	"import.error.bad_language":   "unknown language %q",
+	"import.error.bad_currency":   "unknown currency %q",
+	"import.error.no_name":        "missing name",
+	"import.error.bad_price":      "invalid price %q",
+	"import.error.bad_category":   "unknown category %q",
+	"import.error.bad_status":     "unknown status %q",
+	"import.error.bad_date":       "invalid date %q, expected RFC 3339",
+	"import.error.bad_attribute":  "invalid attribute %s: %q",
+	"import.error.bad_condition":  "unknown condition %q",
+	"import.error.bad_defects":    "unknown defect in the list %q",
+	"import.error.bad_kit":        "unknown kit item in the list %q",
+	"import.error.bad_warranty":   "invalid warranty date %q (expected DD.MM.YYYY or YYYY-MM-DD)",
+	"import.error.bad_imei":       "invalid IMEI %q",
+	"import.error.bad_city":       "unknown city %q",
+	"import.error.bad_location":   "invalid location %q (expected «latitude,longitude»)",
+	"import.error.bad_delivery":   "unknown delivery option in the list %q",
+	"import.error.bad_shipping":   "invalid shipping cost %q (allowed only together with the shipping option)",
+	"import.error.unknown_seller": "seller %d is neither in the database nor in the file",
+
+	"bulk.verified_only":      "Only verified sellers can upload listings from a file.",
+	"bulk.unsupported_format": "CSV and XLSX files are supported.",
+	"bulk.bad_file":           "Could not read the file: %v",
+	"bulk.too_many":           "A single file may contain at most %d listings.",
+	"bulk.expired":            "This preview has expired. Please send the file again.",
+	"bulk.cancelled":          "Upload cancelled.",
+	"bulk.quota":              "The file has %d listings, but your limits allow %d right now.",
+	"bulk.publish_failed":     "Could not publish the listings: storage is temporarily unavailable. Nothing was saved, please try again.",
+	"bulk.published":          "Listings published: %d, sent to moderation: %d.",
+	"bulk.preview.summary":    "Listings found: %d, rows with errors: %d.",
+	"bulk.preview.more":       "…and %d more",
+	"bulk.preview.error":      "Row %d: %s",
+	"bulk.preview.confirm":    "Publish the listings without errors? Rows with errors can be fixed and uploaded in a separate file.",
+	"bulk.error.no_contact":   "no contact: fill in the contact column or set a Telegram username",
+
+	"language.choose":  "Choose the interface language:",
+	"language.changed": "Interface language changed to English.",
+
+	"currency.choose":   "Choose the currency to display prices in. Prices in other currencies will be converted at the current rate.",
+	"currency.changed":  "Prices will be shown in %s.",
+	"currency.original": "No conversion",
+	"currency.reset":    "Prices will be shown in the listing currency.",
+	"currency.unknown":  "This currency is not available right now.",
+
+	"city.choose":     "Choose your city: the «Nearby» and «In my city» buttons use it. Sharing your location via 📎 is the most precise option.",
+	"city.changed":    "Your city: %s.",
+	"city.reset":      "Do not specify",
+	"city.reset_done": "City and location removed.",
+
+	"location.saved":   "Location saved, your city: %s. You can now browse listings by distance.",
+	"location.unknown": "Set your city with /city or share your location via 📎 first.",
+	"location.far":     "Location saved, but there are no cities from the list near it. Choose the nearest city — the «In my city» button uses it:",
+	"location.no_city": "There are no cities from the list near your location. Choose the nearest city:",
+	"location.nearby":  "very close",
+	"location.km":      "%s km",
+
+	"contact.saved":   "Number %s is verified and will be offered as the contact for new listings.",
+	"contact.not_own": "Only your own number can be verified: press «Share phone number» or type the contact as text.",
+
+	"seller.not_found":    "Seller not found.",
+	"seller.anonymous":    "Seller",
+	"seller.no_rating":    "no ratings yet",
+	"seller.rating.one":   "%[2]s of 5 (%[1]d rating)",
+	"seller.rating.other": "%[2]s of 5 (%[1]d ratings)",
+	"seller.page":         "Page %d of %d",
+
+	"button.browse":          "📱 Browse devices",
+	"button.sell":            "💰 Sell a device",
+	"button.search":          "🔍 Search",
+	"button.my_devices":      "📋 My listings",
+	"button.help":            "ℹ️ Help",
+	"button.all_devices":     "All devices",
+	"button.all_in_category": "Everything in this section (%d)",
+	"button.not_listed":      "✏️ Not listed",
+	"button.back":            "« Back",
+	"button.skip":            "Skip",
+	"button.done":            "Done",
+	"button.no_warranty":     "No warranty",
+	"button.free_shipping":   "Free",
+	"button.share_phone":     "📱 Share phone number",
+	"button.back_to_menu":    "« Back to menu",
+	"button.to_categories":   "« To categories",
+	"button.to_main":         "« Main menu",
+	"button.sort_price":      "💰 Cheapest first",
+	"button.sort_nearby":     "📍 Nearby",
+	"button.in_my_city":      "🏙️ In my city",
+	"button.remove":          "❌ Remove listing",
+	"button.mark_sold":       "✅ Sold",
+	"button.seller_profile":  "👤 Seller",
+	"button.share":           "🔗 Share",
+	"button.share_search":    "🔗 Share search",
+	"button.rate_seller":     "⭐ Rate seller",
+	"button.prev_page":       "« Previous",
+	"button.next_page":       "Next »",
+	"button.bulk_publish":    "✅ Publish (%d)",
+	"button.bulk_cancel":     "Cancel",
+	"button.approve":         "✅ Publish",
+	"button.reject":          "🚫 Reject",
+
+	"help.text": `Available actions:
+
+📱 Browse devices - view all available devices
+💰 Sell a device - post a listing
+🔍 Search - find a device by name or description
+📋 My listings - view your listings
+ℹ️ Help - show this message
+
+/language - change the interface language
+/currency - choose the currency for prices
+/city - set your city for nearby search
+
+Choose an action on the keyboard below to get started.`,
+}
+
+var templatesEN = map[string]string{
+	"device_card": `📱 <b>{{.Device.Name}}</b>
+📝 {{.Device.Description}}
+💰 {{price .Device.Price .Device.Currency}}{{with .DisplayPrice}} (≈ {{.}}){{end}}
+🏷️ {{.CategoryName}}{{with .City}}
+📍 {{.}}{{with $.Distance}} (≈ {{.}}){{end}}{{end}}{{with .Delivery}}
+🚚 {{.}}{{end}}{{range .Attributes}}
+▫️ {{.Name}}: {{.Value}}{{end}}{{with .Condition}}
+✨ Condition: {{.}}{{end}}{{if .Condition}}{{if .Defects}}
+⚠️ Defects: {{.Defects}}{{else}}
+✅ No defects{{end}}{{end}}{{with .Kit}}
+📦 Included: {{.}}{{end}}{{with .Warranty}}
+🛡️ Warranty until {{.}}{{end}}{{if .IMEIVerified}}
+🔐 IMEI verified{{end}}
+👤 {{.Device.SellerName}}
+📞 {{.Device.Contact}}{{if .Device.ContactVerified}} ✅ verified{{end}}`,
+
+	"device_added": `✅ <b>Device added!</b>
+Name: {{.Device.Name}}
+Description: {{.Device.Description}}
+Price: {{price .Device.Price .Device.Currency}}
+Category: {{.CategoryName}}{{with .City}}
+City: {{.}}{{end}}{{with .Delivery}}
+Delivery: {{.}}{{end}}{{range .Attributes}}
+{{.Name}}: {{.Value}}{{end}}{{with .Condition}}
+Condition: {{.}}{{end}}{{if .Condition}}{{if .Defects}}
+Defects: {{.Defects}}{{else}}
+No defects{{end}}{{end}}{{with .Kit}}
+Included: {{.}}{{end}}{{with .Warranty}}
+Warranty until {{.}}{{end}}{{if .IMEIVerified}}
+IMEI verified{{end}}`,
+
+	"search_header": `🔍 {{tn "search.found" .Count}} for «<b>{{.Query}}</b>»`,
+
+	"search_empty": `🔍 No devices found for «<b>{{.Query}}</b>».`,
+
+	"category_header": `🏷️ Devices in «<b>{{.CategoryName}}</b>» ({{.Count}}):`,
+
+	"category_empty": `🏷️ There are no devices in «<b>{{.CategoryName}}</b>» yet.`,
+
+	"welcome": `Welcome, <b>{{.FirstName}}</b>! This is a marketplace for mobile devices. Choose an action:`,
+
+	"moderation_info": `⏳ <b>Listing #{{.Device.ID}} is under moderation</b>
+Reason: <i>{{.Device.ModerationNote}}</i>{{with .Device.IMEI}}
+IMEI: <code>{{.}}</code>{{end}}
+
+{{.Card}}`,
+
+	"moderation_approved": `✅ Your listing «<b>{{.Device.Name}}</b>» has passed moderation and is now published.`,
+
+	"moderation_rejected": `🚫 Your listing «<b>{{.Device.Name}}</b>» was rejected by a moderator.`,
+
+	"seller_profile": `👤 <b>{{.Name}}</b>{{if .VerifiedSeller}}
+✅ Verified seller{{end}}{{if .PhoneVerified}}
+📱 Phone number verified{{end}}{{with .JoinedAt}}
+📅 On the marketplace since {{.}}{{end}}
+📦 Active listings: {{.Active}}, sold: {{.Sold}}
+⭐ {{.Rating}}{{range .Listings}}
+
+{{.Number}}. <b>{{.Name}}</b> — {{.Price}}{{with .City}}, {{.}}{{end}}{{end}}{{with .PageInfo}}
+
+{{.}}{{end}}`,
+}
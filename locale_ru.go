@@ -0,0 +1,400 @@
+package main
+
+var catalogRU = map[string]string{
+	"flood.callback": "Слишком много запросов, подождите немного.",
+	"flood.message":  "Вы отправляете слишком много сообщений. Пожалуйста, подождите минуту и попробуйте снова.",
+
+	"command.unknown": "Неизвестная команда. Используйте /help для справки.",
+
+	"menu.choose_action": "Выберите действие:",
+	"menu.main":          "Главное меню:",
+
+	"sell.ask_name":          "Введите название устройства:",
+	"sell.ask_description":   "Введите описание устройства:",
+	"sell.ask_price":         "Введите цену устройства:",
+	"sell.invalid_price":     "Не удалось распознать цену. Введите положительное число, например 15000 или 14999,90:",
+	"sell.ask_currency":      "Выберите валюту цены:",
+	"sell.ask_contact":       "Введите контактные данные для связи или нажмите «Поделиться номером» — номер из Telegram получит отметку «подтвержден»:",
+	"sell.contact_accepted":  "Контакт для объявления: %s",
+	"sell.contact_verified":  "Контакт для объявления: %s ✅ номер подтвержден Telegram",
+	"sell.ask_category":      "Выберите категорию устройства:",
+	"sell.ask_subcategory":   "%s — уточните бренд или модель:",
+	"sell.model_chosen":      "Модель: %s",
+	"sell.moderation":        "Объявление отправлено на модерацию и будет опубликовано после проверки.",
+	"sell.save_failed":       "Не удалось сохранить объявление: хранилище временно недоступно. Введенные данные сохранены — отправьте контакт еще раз, чтобы повторить.",
+	"sell.ask_condition":     "Оцените состояние устройства:\n• Новое — не использовалось, в заводской упаковке\n• Восстановленное — прошло ремонт у производителя или в сервисе\n• Как новое — следов использования не видно\n• Хорошее — мелкие потертости, все работает\n• Удовлетворительное — заметные следы использования или мелкие неисправности\n• На запчасти — не включается или требует серьезного ремонта",
+	"sell.invalid_condition": "Выберите состояние кнопкой:",
+	"sell.ask_defects":       "Отметьте дефекты, если они есть, и нажмите «Готово»:",
+	"sell.ask_kit":           "Что входит в комплект? Отметьте и нажмите «Готово»:",
+	"sell.ask_warranty":      "До какого числа действует гарантия? Введите дату в виде ДД.ММ.ГГГГ или нажмите «Без гарантии».",
+	"sell.invalid_warranty":  "Не удалось распознать дату. Введите дату окончания гарантии в виде ДД.ММ.ГГГГ, не раньше сегодняшней, или нажмите «Без гарантии».",
+	"sell.ask_imei":          "Введите IMEI устройства (15 цифр, его показывает набор *#06#). Покупатели номер не увидят, а объявление получит отметку «IMEI проверен». Если не хотите указывать, нажмите «Пропустить».",
+	"sell.invalid_imei":      "Это не похоже на IMEI: нужно 15 цифр с верной контрольной цифрой. Проверьте номер или нажмите «Пропустить».",
+	"sell.ask_city":          "В каком городе находится устройство? Выберите город или отправьте геопозицию через 📎 — покупатели увидят только город и расстояние.",
+	"sell.invalid_city":      "Этого города нет в списке. Выберите ближайший город кнопкой или отправьте геопозицию через 📎.",
+	"sell.city_far":          "Рядом с этой точкой нет городов из списка. Выберите ближайший город кнопкой — точная геопозиция тоже сохранится.",
+	"sell.ask_delivery":      "Как покупатель может получить устройство? Отметьте все подходящие способы и нажмите «Готово»:",
+	"sell.delivery_required": "Отметьте хотя бы один способ получения.",
+	"sell.ask_shipping_cost": "Сколько стоит отправка почтой или транспортной компанией? Введите сумму в валюте объявления или нажмите «Бесплатно».",
+	"sell.invalid_shipping":  "Введите стоимость отправки числом, например 500, или нажмите «Бесплатно».",
+
+	"attr.storage_gb":     "Память",
+	"attr.ram_gb":         "Оперативная память",
+	"attr.color":          "Цвет",
+	"attr.battery_health": "Состояние аккумулятора",
+	"attr.screen_size":    "Диагональ экрана",
+	"attr.sim_type":       "SIM-карта",
+	"attr.strap_size":     "Размер ремешка",
+
+	"attr.ask.storage_gb":     "Сколько встроенной памяти?",
+	"attr.ask.ram_gb":         "Сколько оперативной памяти?",
+	"attr.ask.color":          "Какого цвета устройство?",
+	"attr.ask.battery_health": "Какое состояние аккумулятора в процентах?",
+	"attr.ask.screen_size":    "Какая диагональ экрана в дюймах?",
+	"attr.ask.sim_type":       "Какие SIM-карты поддерживаются?",
+	"attr.ask.strap_size":     "Какой размер ремешка?",
+	"attr.hint.number":        "Введите число от %s до %s или нажмите «Пропустить».",
+	"attr.hint.choice":        "Выберите вариант или нажмите «Пропустить».",
+	"attr.invalid":            "Не удалось распознать значение.",
+
+	"attr.unit.gb":      " ГБ",
+	"attr.unit.percent": "%",
+	"attr.unit.inch":    "″",
+
+	"attr.color.black":  "Черный",
+	"attr.color.white":  "Белый",
+	"attr.color.silver": "Серебристый",
+	"attr.color.gold":   "Золотой",
+	"attr.color.blue":   "Синий",
+	"attr.color.red":    "Красный",
+	"attr.color.green":  "Зеленый",
+	"attr.color.purple": "Фиолетовый",
+	"attr.color.pink":   "Розовый",
+	"attr.color.other":  "Другой",
+
+	"attr.sim_type.nano":      "nano-SIM",
+	"attr.sim_type.dual_nano": "2 × nano-SIM",
+	"attr.sim_type.nano_esim": "nano-SIM + eSIM",
+	"attr.sim_type.esim":      "Только eSIM",
+	"attr.sim_type.none":      "Без SIM",
+
+	"attr.strap_size.xs": "XS",
+	"attr.strap_size.s":  "S",
+	"attr.strap_size.m":  "M",
+	"attr.strap_size.l":  "L",
+	"attr.strap_size.xl": "XL",
+
+	"condition.new":         "Новое",
+	"condition.refurbished": "Восстановленное",
+	"condition.like_new":    "Как новое",
+	"condition.good":        "Хорошее",
+	"condition.fair":        "Удовлетворительное",
+	"condition.for_parts":   "На запчасти",
+
+	"defect.cracked_screen": "Трещина на экране",
+	"defect.scratches":      "Царапины",
+	"defect.dents":          "Сколы и вмятины на корпусе",
+	"defect.dead_pixels":    "Битые пиксели",
+	"defect.battery_worn":   "Быстро садится аккумулятор",
+	"defect.camera":         "Не работает камера",
+	"defect.buttons":        "Неисправны кнопки",
+	"defect.biometrics":     "Не работает Face ID или отпечаток",
+	"defect.water_damage":   "Было попадание воды",
+
+	"kit.box":     "Коробка",
+	"kit.charger": "Зарядное устройство",
+	"kit.cable":   "Кабель",
+	"kit.receipt": "Чек",
+
+	"delivery.pickup":   "Самовывоз",
+	"delivery.meetup":   "Встреча",
+	"delivery.courier":  "Курьер",
+	"delivery.shipping": "Почта",
+	"delivery.free":     "бесплатно",
+
+	"error.storage": "Не удалось выполнить действие: хранилище временно недоступно. Попробуйте еще раз через минуту.",
+
+	"browse.choose_category":    "Выберите категорию:",
+	"browse.choose_subcategory": "%s — выберите раздел:",
+	"browse.other_category":     "Выберите другую категорию или вернитесь в главное меню:",
+	"browse.empty":              "Сейчас нет доступных устройств.",
+	"browse.header.one":         "Доступно %d устройство:",
+	"browse.header.few":         "Доступно %d устройства:",
+	"browse.header.many":        "Доступно %d устройств:",
+	"browse.sorted.one":         "%d устройство, сначала дешевые:",
+	"browse.sorted.few":         "%d устройства, сначала дешевые:",
+	"browse.sorted.many":        "%d устройств, сначала дешевые:",
+	"browse.nearby.one":         "%d устройство, сначала ближайшие:",
+	"browse.nearby.few":         "%d устройства, сначала ближайшие:",
+	"browse.nearby.many":        "%d устройств, сначала ближайшие:",
+	"browse.in_city.one":        "%d устройство в вашем городе, сначала дешевые:",
+	"browse.in_city.few":        "%d устройства в вашем городе, сначала дешевые:",
+	"browse.in_city.many":       "%d устройств в вашем городе, сначала дешевые:",
+	"browse.empty_city":         "В городе %s сейчас нет таких устройств.",
+	"browse.back":               "Вернуться к категориям или в главное меню:",
+
+	"category.unknown":     "Не указана",
+	"category.unavailable": "Эта категория больше недоступна, выберите другую:",
+
+	"search.ask":        "Введите поисковый запрос (название или описание). Можно добавить фильтры по характеристикам, например: память:128 акб>=85 состояние>=хорошее гарантия:да город:москва доставка:почта цена<=30000",
+	"search.found.one":  "Найдено %d устройство",
+	"search.found.few":  "Найдено %d устройства",
+	"search.found.many": "Найдено %d устройств",
+
+	"my.empty":       "У вас пока нет объявлений.",
+	"my.header.one":  "У вас %d объявление:",
+	"my.header.few":  "У вас %d объявления:",
+	"my.header.many": "У вас %d объявлений:",
+	"my.back":        "Вернуться в главное меню:",
+
+	"device.not_found":     "Устройство не найдено.",
+	"device.not_owner":     "Вы не можете изменить объявление другого пользователя.",
+	"device.removed":       "Объявление удалено.",
+	"device.remove_failed": "Не удалось удалить объявление.",
+	"device.sold":          "Объявление отмечено как проданное и снято с витрины.",
+	"device.not_active":    "Отметить проданным можно только опубликованное объявление. Возможно, оно еще на модерации, уже продано или удалено.",
+
+	"status.moderation": "⏳ На модерации",
+	"status.rejected":   "🚫 Отклонено модератором",
+	"status.sold":       "✅ Продано",
+
+	"quota.active.one":  "У вас уже %d активное объявление — это максимум. Удалите неактуальные объявления, чтобы разместить новое.",
+	"quota.active.few":  "У вас уже %d активных объявления — это максимум. Удалите неактуальные объявления, чтобы разместить новое.",
+	"quota.active.many": "У вас уже %d активных объявлений — это максимум. Удалите неактуальные объявления, чтобы разместить новое.",
+	"quota.daily.one":   "За последние сутки вы разместили %d объявление — это максимум. Попробуйте снова позже.",
+	"quota.daily.few":   "За последние сутки вы разместили %d объявления — это максимум. Попробуйте снова позже.",
+	"quota.daily.many":  "За последние сутки вы разместили %d объявлений — это максимум. Попробуйте снова позже.",
+
+	"moderation.admin_only_command": "Команда доступна только модераторам.",
+	"moderation.admin_only_action":  "Действие доступно только модераторам.",
+	"moderation.empty":              "Нет объявлений, ожидающих модерации.",
+	"moderation.header.one":         "На модерации %d объявление:",
+	"moderation.header.few":         "На модерации %d объявления:",
+	"moderation.header.many":        "На модерации %d объявлений:",
+	"moderation.batch.one":          "%d объявление от %s из пакетной загрузки ждет модерации: /moderation",
+	"moderation.batch.few":          "%d объявления от %s из пакетной загрузки ждут модерации: /moderation",
+	"moderation.batch.many":         "%d объявлений от %s из пакетной загрузки ждут модерации: /moderation",
+	"moderation.approved":           "Объявление #%d опубликовано.",
+	"moderation.rejected":           "Объявление #%d отклонено.",
+	"moderation.already_handled":    "Объявление #%d уже не на модерации: решение по нему принято раньше.",
+
+	"backup.done":        "Резервная копия базы: %s",
+	"backup.failed":      "Не удалось сделать резервную копию, подробности в журнале бота.",
+	"backup.too_large":   "Резервная копия сохранена на сервере в %s, но слишком велика для отправки в Telegram.",
+	"backup.unsupported": "Резервное копирование из бота доступно только для базы SQLite.",
+
+	"export.usage": "Использование: /export json или /export csv",
+	"export.done":  "Выгружено пользователей: %d, объявлений: %d",
+
+	"categories.header":   "Категории каталога (порядок, эмодзи, код, названия):",
+	"categories.item":     "%d. %s %s — %s %s",
+	"categories.enabled":  "✅",
+	"categories.disabled": "🚫 скрыта",
+
+	"category.usage": `Изменение категорий:
+/category set <код> <эмодзи или -> <Название> | <Name> — создать или переименовать
+/category parent <код> <код родителя или -> — перенести в другой раздел
+/category order <код> <число> — место в списке
+/category off <код> — скрыть из клавиатур, объявления останутся
+/category on <код> — вернуть категорию`,
+	"category.error.bad_code":     "Некорректный код %q: допустимы латинские буквы в нижнем регистре, цифры и «_», не длиннее 32 символов.",
+	"category.error.no_name":      "Укажите название категории.",
+	"category.error.not_found":    "Категория %s не найдена. Список категорий: /categories",
+	"category.error.bad_order":    "Порядок должен быть целым числом, а не %q.",
+	"category.error.last_enabled": "Нельзя скрыть последнюю видимую категорию.",
+	"category.error.cycle":        "Нельзя перенести категорию внутрь нее самой.",
+
+	"import.ask_file":        "Пришлите файл JSON (как из /export) или CSV с пользователями или объявлениями.",
+	"import.dry_run_note":    "Режим проверки: файл будет проверен, но изменения не сохранятся.",
+	"import.cancelled":       "Импорт отменен.",
+	"import.too_large":       "Файл слишком большой: Telegram позволяет ботам скачивать файлы до 20 МБ.",
+	"import.download_failed": "Не удалось скачать файл, попробуйте еще раз.",
+	"import.bad_file":        "Не удалось прочитать файл: %v",
+	"import.failed":          "Импорт не выполнен, данные не изменены. Подробности в журнале бота.",
+
+	"import.report.dry_run":     "Проверка без сохранения:",
+	"import.report.summary":     "Пользователей добавлено: %d, уже были: %d\nОбъявлений добавлено: %d, дубликатов пропущено: %d\nОшибок: %d",
+	"import.report.error":       "%s, запись %d: %s",
+	"import.report.more_errors": "…и еще ошибок: %d",
+	"import.section.users":      "Пользователи",
+	"import.section.devices":    "Объявления",
+
+	"import.error.bad_id":         "некорректный ID",
+	"import.error.duplicate_user": "пользователь %d повторяется в файле",
+	"import.error.bad_language":   "неизвестный язык %q",
+	"import.error.bad_currency":   "неизвестная валюта %q",
+	"import.error.no_name":        "нет названия",
+	"import.error.bad_price":      "некорректная цена %q",
+	"import.error.bad_category":   "неизвестная категория %q",
+	"import.error.bad_status":     "неизвестный статус %q",
+	"import.error.bad_date":       "некорректная дата %q, ожидается формат RFC 3339",
+	"import.error.bad_attribute":  "некорректная характеристика %s: %q",
+	"import.error.bad_condition":  "неизвестное состояние %q",
+	"import.error.bad_defects":    "неизвестный дефект в списке %q",
+	"import.error.bad_kit":        "неизвестный предмет комплекта в списке %q",
+	"import.error.bad_warranty":   "некорректная дата гарантии %q (ожидается ДД.ММ.ГГГГ или ГГГГ-ММ-ДД)",
+	"import.error.bad_imei":       "некорректный IMEI %q",
+	"import.error.bad_city":       "неизвестный город %q",
+	"import.error.bad_location":   "некорректная геопозиция %q (ожидается «широта,долгота»)",
+	"import.error.bad_delivery":   "неизвестный способ получения в списке %q",
+	"import.error.bad_shipping":   "некорректная стоимость отправки %q (указывается только вместе со способом shipping)",
+	"import.error.unknown_seller": "продавец %d не найден ни в базе, ни в файле",
+
+	"bulk.verified_only":      "Загружать объявления файлом могут только проверенные продавцы.",
+	"bulk.unsupported_format": "Поддерживаются файлы CSV и XLSX.",
+	"bulk.bad_file":           "Не удалось прочитать файл: %v",
+	"bulk.too_many":           "В одном файле можно загрузить не больше %d объявлений.",
+	"bulk.expired":            "Предпросмотр устарел. Пришлите файл еще раз.",
+	"bulk.cancelled":          "Загрузка отменена.",
+	"bulk.quota":              "В файле %d объявлений, а по вашим лимитам сейчас можно разместить %d.",
+	"bulk.publish_failed":     "Не удалось опубликовать объявления: хранилище временно недоступно. Ни одно объявление не сохранено, попробуйте еще раз.",
+	"bulk.published":          "Опубликовано объявлений: %d, отправлено на модерацию: %d.",
+	"bulk.preview.summary":    "Найдено объявлений: %d, строк с ошибками: %d.",
+	"bulk.preview.more":       "…и еще %d",
+	"bulk.preview.error":      "Строка %d: %s",
+	"bulk.preview.confirm":    "Опубликовать объявления без ошибок? Строки с ошибками можно исправить и загрузить отдельным файлом.",
+	"bulk.error.no_contact":   "нет контакта: заполните колонку contact или укажите username в Telegram",
+
+	"language.choose":  "Выберите язык интерфейса:",
+	"language.changed": "Язык интерфейса изменен на русский.",
+
+	"currency.choose":   "Выберите валюту, в которой показывать цены. Цены в других валютах будут пересчитаны по курсу.",
+	"currency.changed":  "Цены будут показаны в валюте %s.",
+	"currency.original": "Без пересчета",
+	"currency.reset":    "Цены будут показаны в валюте объявления.",
+	"currency.unknown":  "Эта валюта сейчас недоступна.",
+
+	"city.choose":     "Выберите свой город: по нему работают кнопки «Рядом» и «В моем городе». Точнее всего — отправить геопозицию через 📎.",
+	"city.changed":    "Ваш город: %s.",
+	"city.reset":      "Не указывать",
+	"city.reset_done": "Город и геопозиция удалены.",
+
+	"location.saved":   "Геопозиция сохранена, ваш город: %s. Теперь объявления можно смотреть по расстоянию.",
+	"location.unknown": "Сначала укажите город командой /city или отправьте геопозицию через 📎.",
+	"location.far":     "Геопозиция сохранена, но рядом с ней нет городов из списка. Выберите ближайший город — по нему работает кнопка «В моем городе»:",
+	"location.no_city": "Рядом с вашей геопозицией нет городов из списка. Выберите ближайший город:",
+	"location.nearby":  "совсем рядом",
+	"location.km":      "%s км",
+
+	"contact.saved":   "Номер %s подтвержден и будет предложен как контакт в новых объявлениях.",
+	"contact.not_own": "Подтвердить можно только свой номер: нажмите кнопку «Поделиться номером» или введите контакт текстом.",
+
+	"seller.not_found":   "Продавец не найден.",
+	"seller.anonymous":   "Продавец",
+	"seller.no_rating":   "пока нет оценок",
+	"seller.rating.one":  "%[2]s из 5 (%[1]d оценка)",
+	"seller.rating.few":  "%[2]s из 5 (%[1]d оценки)",
+	"seller.rating.many": "%[2]s из 5 (%[1]d оценок)",
+	"seller.page":        "Страница %d из %d",
+
+	"button.browse":          "📱 Посмотреть устройства",
+	"button.sell":            "💰 Продать устройство",
+	"button.search":          "🔍 Поиск",
+	"button.my_devices":      "📋 Мои объявления",
+	"button.help":            "ℹ️ Помощь",
+	"button.all_devices":     "Все устройства",
+	"button.all_in_category": "Все в этом разделе (%d)",
+	"button.not_listed":      "✏️ Нет в списке",
+	"button.back":            "« Назад",
+	"button.skip":            "Пропустить",
+	"button.done":            "Готово",
+	"button.no_warranty":     "Без гарантии",
+	"button.free_shipping":   "Бесплатно",
+	"button.share_phone":     "📱 Поделиться номером",
+	"button.back_to_menu":    "« Назад в меню",
+	"button.to_categories":   "« К категориям",
+	"button.to_main":         "« В главное меню",
+	"button.sort_price":      "💰 Сначала дешевые",
+	"button.sort_nearby":     "📍 Рядом",
+	"button.in_my_city":      "🏙️ В моем городе",
+	"button.remove":          "❌ Удалить объявление",
+	"button.mark_sold":       "✅ Продано",
+	"button.seller_profile":  "👤 Продавец",
+	"button.share":           "🔗 Поделиться",
+	"button.share_search":    "🔗 Поделиться поиском",
+	"button.rate_seller":     "⭐ Оценить продавца",
+	"button.prev_page":       "« Назад",
+	"button.next_page":       "Далее »",
+	"button.bulk_publish":    "✅ Опубликовать (%d)",
+	"button.bulk_cancel":     "Отмена",
+	"button.approve":         "✅ Опубликовать",
+	"button.reject":          "🚫 Отклонить",
+
+	"help.text": `Доступные действия:
+
+📱 Посмотреть устройства - просмотр всех доступных устройств
+💰 Продать устройство - разместить объявление о продаже
+🔍 Поиск - поиск устройства по названию или описанию
+📋 Мои объявления - просмотр ваших объявлений
+ℹ️ Помощь - показать это сообщение
+
+/language - сменить язык интерфейса
+/currency - выбрать валюту для отображения цен
+/city - указать свой город для поиска рядом
+
+Для начала работы выберите действие на клавиатуре ниже.`,
+}
+
+var templatesRU = map[string]string{
+	"device_card": `📱 <b>{{.Device.Name}}</b>
+📝 {{.Device.Description}}
+💰 {{price .Device.Price .Device.Currency}}{{with .DisplayPrice}} (≈ {{.}}){{end}}
+🏷️ {{.CategoryName}}{{with .City}}
+📍 {{.}}{{with $.Distance}} (≈ {{.}}){{end}}{{end}}{{with .Delivery}}
+🚚 {{.}}{{end}}{{range .Attributes}}
+▫️ {{.Name}}: {{.Value}}{{end}}{{with .Condition}}
+✨ Состояние: {{.}}{{end}}{{if .Condition}}{{if .Defects}}
+⚠️ Дефекты: {{.Defects}}{{else}}
+✅ Без дефектов{{end}}{{end}}{{with .Kit}}
+📦 В комплекте: {{.}}{{end}}{{with .Warranty}}
+🛡️ Гарантия до {{.}}{{end}}{{if .IMEIVerified}}
+🔐 IMEI проверен{{end}}
+👤 {{.Device.SellerName}}
+📞 {{.Device.Contact}}{{if .Device.ContactVerified}} ✅ номер подтвержден{{end}}`,
+
+	"device_added": `✅ <b>Устройство добавлено!</b>
+Название: {{.Device.Name}}
+Описание: {{.Device.Description}}
+Цена: {{price .Device.Price .Device.Currency}}
+Категория: {{.CategoryName}}{{with .City}}
+Город: {{.}}{{end}}{{with .Delivery}}
+Получение: {{.}}{{end}}{{range .Attributes}}
+{{.Name}}: {{.Value}}{{end}}{{with .Condition}}
+Состояние: {{.}}{{end}}{{if .Condition}}{{if .Defects}}
+Дефекты: {{.Defects}}{{else}}
+Без дефектов{{end}}{{end}}{{with .Kit}}
+В комплекте: {{.}}{{end}}{{with .Warranty}}
+Гарантия до {{.}}{{end}}{{if .IMEIVerified}}
+IMEI проверен{{end}}`,
+
+	"search_header": `🔍 {{tn "search.found" .Count}} по запросу «<b>{{.Query}}</b>»`,
+
+	"search_empty": `🔍 По запросу «<b>{{.Query}}</b>» устройства не найдены.`,
+
+	"category_header": `🏷️ Устройства в категории «<b>{{.CategoryName}}</b>» ({{.Count}}):`,
+
+	"category_empty": `🏷️ В категории «<b>{{.CategoryName}}</b>» пока нет устройств.`,
+
+	"welcome": `Добро пожаловать, <b>{{.FirstName}}</b>! Это маркетплейс мобильных устройств. Выберите действие:`,
+
+	"moderation_info": `⏳ <b>Объявление #{{.Device.ID}} на модерации</b>
+Причина: <i>{{.Device.ModerationNote}}</i>{{with .Device.IMEI}}
+IMEI: <code>{{.}}</code>{{end}}
+
+{{.Card}}`,
+
+	"moderation_approved": `✅ Ваше объявление «<b>{{.Device.Name}}</b>» прошло модерацию и опубликовано.`,
+
+	"moderation_rejected": `🚫 Ваше объявление «<b>{{.Device.Name}}</b>» отклонено модератором.`,
+
+	"seller_profile": `👤 <b>{{.Name}}</b>{{if .VerifiedSeller}}
+✅ Проверенный продавец{{end}}{{if .PhoneVerified}}
+📱 Номер подтвержден{{end}}{{with .JoinedAt}}
+📅 На маркетплейсе с {{.}}{{end}}
+📦 Активных объявлений: {{.Active}}, продано: {{.Sold}}
+⭐ {{.Rating}}{{range .Listings}}
+
+{{.Number}}. <b>{{.Name}}</b> — {{.Price}}{{with .City}}, {{.}}{{end}}{{end}}{{with .PageInfo}}
+
+{{.}}{{end}}`,
+}
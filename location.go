@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// GeoPoint — координаты из геопозиции Telegram; нулевое значение означает, что
+// координат нет. Точная точка продавца покупателям не показывается: в карточке
+// только город и расстояние.
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (p GeoPoint) IsZero() bool {
+	return p.Latitude == 0 && p.Longitude == 0
+}
+
+type City struct {
+	Code  string
+	Names map[string]string
+	// Центр города: по нему считается расстояние, если точной геопозиции нет
+	Center GeoPoint
+	// Сокращения, которые принимаются при вводе и в фильтре поиска
+	Aliases []string
+}
+
+func (c City) Name(lang string) string {
+	if name, ok := c.Names[lang]; ok {
+		return name
+	}
+	return c.Names[defaultLanguage]
+}
+
+// Города-миллионники и крупные региональные центры. Геопозиция из другого
+// населенного пункта привязывается к ближайшему городу списка, если он не дальше
+// maxCityDistanceKm; иначе город выбирают вручную.
+var cities = []City{
+	{"moscow", map[string]string{LangRU: "Москва", LangEN: "Moscow"}, GeoPoint{55.7558, 37.6173}, []string{"мск"}},
+	{"saint_petersburg", map[string]string{LangRU: "Санкт-Петербург", LangEN: "Saint Petersburg"}, GeoPoint{59.9343, 30.3351}, []string{"спб", "питер", "spb"}},
+	{"novosibirsk", map[string]string{LangRU: "Новосибирск", LangEN: "Novosibirsk"}, GeoPoint{55.0084, 82.9357}, []string{"нск"}},
+	{"yekaterinburg", map[string]string{LangRU: "Екатеринбург", LangEN: "Yekaterinburg"}, GeoPoint{56.8389, 60.6057}, []string{"екб"}},
+	{"kazan", map[string]string{LangRU: "Казань", LangEN: "Kazan"}, GeoPoint{55.7961, 49.1064}, nil},
+	{"nizhny_novgorod", map[string]string{LangRU: "Нижний Новгород", LangEN: "Nizhny Novgorod"}, GeoPoint{56.2965, 43.9361}, []string{"нн"}},
+	{"chelyabinsk", map[string]string{LangRU: "Челябинск", LangEN: "Chelyabinsk"}, GeoPoint{55.1644, 61.4368}, nil},
+	{"krasnoyarsk", map[string]string{LangRU: "Красноярск", LangEN: "Krasnoyarsk"}, GeoPoint{56.0153, 92.8932}, nil},
+	{"samara", map[string]string{LangRU: "Самара", LangEN: "Samara"}, GeoPoint{53.1959, 50.1002}, nil},
+	{"ufa", map[string]string{LangRU: "Уфа", LangEN: "Ufa"}, GeoPoint{54.7388, 55.9721}, nil},
+	{"rostov_on_don", map[string]string{LangRU: "Ростов-на-Дону", LangEN: "Rostov-on-Don"}, GeoPoint{47.2357, 39.7015}, []string{"ростов"}},
+	{"omsk", map[string]string{LangRU: "Омск", LangEN: "Omsk"}, GeoPoint{54.9885, 73.3242}, nil},
+	{"krasnodar", map[string]string{LangRU: "Краснодар", LangEN: "Krasnodar"}, GeoPoint{45.0355, 38.9753}, nil},
+	{"voronezh", map[string]string{LangRU: "Воронеж", LangEN: "Voronezh"}, GeoPoint{51.6720, 39.1843}, nil},
+	{"perm", map[string]string{LangRU: "Пермь", LangEN: "Perm"}, GeoPoint{58.0105, 56.2502}, nil},
+	{"volgograd", map[string]string{LangRU: "Волгоград", LangEN: "Volgograd"}, GeoPoint{48.7080, 44.5133}, nil},
+}
+
+func findCity(code string) (City, bool) {
+	for _, city := range cities {
+		if city.Code == code {
+			return city, true
+		}
+	}
+	return City{}, false
+}
+
+// resolveCity находит город по коду, названию на любом языке или сокращению
+func resolveCity(input string) (City, bool) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	for _, city := range cities {
+		if input == city.Code {
+			return city, true
+		}
+		for _, name := range city.Names {
+			if input == strings.ToLower(name) {
+				return city, true
+			}
+		}
+		for _, alias := range city.Aliases {
+			if input == alias {
+				return city, true
+			}
+		}
+	}
+	return City{}, false
+}
+
+// resolveCityCode возвращает код города или пустую строку, если город не найден
+func resolveCityCode(input string) string {
+	city, _ := resolveCity(input)
+	return city.Code
+}
+
+// Дальше этого расстояния от центра город списка не считается городом точки:
+// без ограничения Сочи оказался бы Краснодаром, а Владивосток — Красноярском
+const maxCityDistanceKm = 100
+
+// nearestCity возвращает ближайший город списка; false, если все города дальше
+// maxCityDistanceKm
+func nearestCity(point GeoPoint) (City, bool) {
+	nearest := cities[0]
+	for _, city := range cities[1:] {
+		if distanceKm(point, city.Center) < distanceKm(point, nearest.Center) {
+			nearest = city
+		}
+	}
+	if distanceKm(point, nearest.Center) > maxCityDistanceKm {
+		return City{}, false
+	}
+	return nearest, true
+}
+
+func cityName(lang, code string) string {
+	if city, ok := findCity(code); ok {
+		return city.Name(lang)
+	}
+	return ""
+}
+
+func formatGeoPoint(point GeoPoint) string {
+	return strconv.FormatFloat(point.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(point.Longitude, 'f', -1, 64)
+}
+
+func parseGeoPoint(value string) (GeoPoint, bool) {
+	latText, lonText, ok := strings.Cut(value, ",")
+	if !ok {
+		return GeoPoint{}, false
+	}
+	latitude, latErr := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	longitude, lonErr := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if latErr != nil || lonErr != nil || math.Abs(latitude) > 90 || math.Abs(longitude) > 180 {
+		return GeoPoint{}, false
+	}
+	return GeoPoint{Latitude: latitude, Longitude: longitude}, true
+}
+
+const earthRadiusKm = 6371
+
+// distanceKm считает расстояние по большому кругу (формула гаверсинусов)
+func distanceKm(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// devicePoint возвращает геопозицию объявления или центр его города
+func devicePoint(device Device) (GeoPoint, bool) {
+	if !device.Location.IsZero() {
+		return device.Location, true
+	}
+	if city, ok := findCity(device.City); ok {
+		return city.Center, true
+	}
+	return GeoPoint{}, false
+}
+
+// buyerPoint возвращает геопозицию покупателя или центр города по умолчанию
+func buyerPoint(user User) (GeoPoint, bool) {
+	if !user.Location.IsZero() {
+		return user.Location, true
+	}
+	if city, ok := findCity(user.City); ok {
+		return city.Center, true
+	}
+	return GeoPoint{}, false
+}
+
+// sortDevicesByDistance ставит ближайшие объявления первыми; объявления без города
+// и геопозиции идут в конце
+func sortDevicesByDistance(devices []Device, from GeoPoint) {
+	distance := func(device Device) float64 {
+		if point, ok := devicePoint(device); ok {
+			return distanceKm(from, point)
+		}
+		return math.Inf(1)
+	}
+	sort.SliceStable(devices, func(i, j int) bool { return distance(devices[i]) < distance(devices[j]) })
+}
+
+func filterDevicesByCity(devices []Device, city string) []Device {
+	var result []Device
+	for _, device := range devices {
+		if device.City == city {
+			result = append(result, device)
+		}
+	}
+	return result
+}
+
+// formatDistance округляет расстояние: до ста метров — «рядом», дальше — в километрах
+func formatDistance(lang string, km float64) string {
+	if km < 0.1 {
+		return T(lang, "location.nearby")
+	}
+	if km < 10 {
+		return T(lang, "location.km", fmt.Sprintf("%.1f", km))
+	}
+	return T(lang, "location.km", fmt.Sprintf("%.0f", km))
+}
+
+// cityFilter — фильтр поиска город:москва или city:spb
+func cityFilter(name, op, value string) (searchFilter, bool) {
+	if op != "=" || (name != "city" && name != "город") {
+		return nil, false
+	}
+	city, ok := resolveCity(value)
+	if !ok {
+		return nil, false
+	}
+	return func(device Device) bool { return device.City == city.Code }, true
+}
+
+// getCityKeyboard предлагает города списка; текущий город пользователя — первым.
+// Callback — <prefix><код>, кнопка сброса — <prefix>none, если задан noneLabel.
+func getCityKeyboard(lang, prefix, current, noneLabel string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if city, ok := findCity(current); ok {
+		button := tgbotapi.NewInlineKeyboardButtonData("📍 "+city.Name(lang), prefix+city.Code)
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{button})
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, city := range cities {
+		if city.Code == current {
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(city.Name(lang), prefix+city.Code))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	if noneLabel != "" {
+		rows = append(rows, []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData(noneLabel, prefix+"none")})
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,40 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocationRoundTrip(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	if err := db.SaveUser(User{ID: 1, City: "perm", Location: GeoPoint{58.01, 56.25}}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	users, err := db.GetUsers()
+	if err != nil || users[1].City != "perm" || users[1].Location.Longitude != 56.25 {
+		t.Fatalf("пользователь после чтения: %+v, %v", users[1], err)
+	}
+
+	located, err := db.SaveDevice(Device{Name: "iPad", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: CategoryTablet, Status: DeviceStatusActive, City: "perm", Location: GeoPoint{58.01, 56.25}})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+	plain, err := db.SaveDevice(Device{Name: "iPad", SellerID: 1, Price: 100, Currency: CurrencyRUB,
+		Category: CategoryTablet, Status: DeviceStatusActive})
+	if err != nil {
+		t.Fatalf("SaveDevice: %v", err)
+	}
+
+	device, _, err := db.GetDeviceByID(located)
+	if err != nil || device.City != "perm" || device.Location.Latitude != 58.01 {
+		t.Fatalf("объявление после чтения: %+v, %v", device, err)
+	}
+	device, _, err = db.GetDeviceByID(plain)
+	if err != nil || device.City != "" || !device.Location.IsZero() {
+		t.Fatalf("объявление без города: %+v, %v", device, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceAndNearestCity(t *testing.T) {
+	moscow, _ := findCity("moscow")
+	petersburg, _ := findCity("saint_petersburg")
+	if d := distanceKm(moscow.Center, petersburg.Center); math.Abs(d-634) > 5 {
+		t.Errorf("Москва — Петербург: %.0f км", d)
+	}
+	// Подмосковье привязывается к Москве, Пулково — к Петербургу
+	if city, ok := nearestCity(GeoPoint{55.9, 37.4}); !ok || city.Code != "moscow" {
+		t.Errorf("nearestCity(Химки) = %s, %v", city.Code, ok)
+	}
+	if city, ok := nearestCity(GeoPoint{59.8, 30.26}); !ok || city.Code != "saint_petersburg" {
+		t.Errorf("nearestCity(Пулково) = %s, %v", city.Code, ok)
+	}
+	// Вдали от городов списка город не подбирается
+	for name, point := range map[string]GeoPoint{"Сочи": {43.5855, 39.7231}, "Владивосток": {43.1155, 131.8855}} {
+		if city, ok := nearestCity(point); ok {
+			t.Errorf("nearestCity(%s) = %s", name, city.Code)
+		}
+	}
+}
+
+func TestResolveCityAndGeoPoint(t *testing.T) {
+	for input, want := range map[string]string{"Москва": "moscow", " спб ": "saint_petersburg", "Kazan": "kazan",
+		"rostov_on_don": "rostov_on_don", "Тверь": ""} {
+		if got := resolveCityCode(input); got != want {
+			t.Errorf("resolveCityCode(%q) = %q; ожидался %q", input, got, want)
+		}
+	}
+
+	point, ok := parseGeoPoint(" 55.7558, 37.6173 ")
+	if !ok || point.Latitude != 55.7558 || formatGeoPoint(point) != "55.7558,37.6173" {
+		t.Errorf("parseGeoPoint: %+v, %v", point, ok)
+	}
+	for _, bad := range []string{"", "55.75", "95,37", "55,юг"} {
+		if _, ok := parseGeoPoint(bad); ok {
+			t.Errorf("parseGeoPoint(%q) принял некорректное значение", bad)
+		}
+	}
+}
+
+func TestSortByDistanceAndCityFilter(t *testing.T) {
+	devices := []Device{
+		{ID: 1, City: "kazan"},
+		{ID: 2},
+		{ID: 3, City: "moscow", Location: GeoPoint{55.9, 37.4}},
+		{ID: 4, City: "saint_petersburg"},
+	}
+	sortDevicesByDistance(devices, GeoPoint{55.75, 37.62})
+	for i, want := range []int{3, 4, 1, 2} {
+		if devices[i].ID != want {
+			t.Fatalf("порядок по расстоянию: %+v", devices)
+		}
+	}
+
+	_, filters := parseSearchQuery("город:спб", nil, "")
+	if len(filters) != 1 || !matchesFilters(devices[1], filters) || matchesFilters(devices[0], filters) {
+		t.Errorf("фильтр город:спб: %d фильтров", len(filters))
+	}
+	if inCity := filterDevicesByCity(devices, "kazan"); len(inCity) != 1 || inCity[0].ID != 1 {
+		t.Errorf("filterDevicesByCity: %+v", inCity)
+	}
+}
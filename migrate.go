@@ -0,0 +1,307 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Миграции схемы лежат в migrations/<СУБД> и встраиваются в бинарник.
+// Имя файла задает номер версии: 0007_short_name.sql. Уже выпущенные
+// миграции не редактируются — любое изменение схемы оформляется новым файлом
+// с одинаковым номером для каждой СУБД.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("некорректное имя миграции %s", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{
+			Version: version,
+			Name:    strings.TrimSuffix(entry.Name(), ".sql"),
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("пропущена или повторяется миграция с номером %d (%s)", i+1, m.Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// migrate применяет все непримененные миграции в одной транзакции
+func (d *Database) migrate() error {
+	applied, err := d.runMigrations(true)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		log.Printf("Применена миграция %s", m.Name)
+	}
+	return nil
+}
+
+// DryRunMigrations выполняет непримененные миграции и откатывает транзакцию:
+// так проверяется, что они применятся к этой базе, но сама база не меняется
+func (d *Database) DryRunMigrations() ([]migration, error) {
+	return d.runMigrations(false)
+}
+
+func (d *Database) runMigrations(commit bool) ([]migration, error) {
+	migrations, err := loadMigrations(migrationFiles, d.dialect.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	return d.applyMigrations(migrations, commit)
+}
+
+// applyMigrations применяет недостающие миграции из списка; при ошибке транзакция
+// откатывается целиком, и база остается в прежней версии
+func (d *Database) applyMigrations(migrations []migration, commit bool) ([]migration, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if d.dialect.migrationLock != "" {
+		if _, err := tx.Exec(d.dialect.migrationLock); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(applied) == 0 {
+		legacyVersion, err := detectLegacyVersion(tx, d.dialect, len(migrations))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range migrations[:legacyVersion] {
+			if err := recordMigration(tx, d.dialect, m); err != nil {
+				return nil, err
+			}
+			applied[m.Version] = time.Now()
+		}
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			return pending, fmt.Errorf("миграция %s: %v", m.Name, err)
+		}
+		if err := recordMigration(tx, d.dialect, m); err != nil {
+			return pending, err
+		}
+		pending = append(pending, m)
+	}
+
+	if !commit {
+		return pending, nil
+	}
+	return pending, tx.Commit()
+}
+
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(migrationFiles, d.dialect.migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+	exists, err := hasTable(d.db, d.dialect, "schema_version")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = appliedMigrations(d.db); err != nil {
+			return nil, err
+		}
+	}
+
+	// Для старой базы без schema_version показываем версию, определенную по колонкам
+	if len(applied) == 0 {
+		legacyVersion, err := detectLegacyVersion(d.db, d.dialect, len(migrations))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range migrations[:legacyVersion] {
+			applied[m.Version] = time.Time{}
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+func appliedMigrations(q queryer) (map[int]time.Time, error) {
+	rows, err := q.Query(`SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func recordMigration(q queryer, dialect dialect, m migration) error {
+	_, err := q.Exec(dialect.rebind(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.Version, m.Name, time.Now())
+	return err
+}
+
+func detectLegacyVersion(q queryer, dialect dialect, latest int) (int, error) {
+	version := 0
+	for version < latest {
+		marker, ok := dialect.legacyMarkers[version+1]
+		if !ok {
+			break
+		}
+		exists, err := hasColumn(q, dialect, marker[0], marker[1])
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			break
+		}
+		version++
+	}
+	return version, nil
+}
+
+func hasTable(q queryer, dialect dialect, table string) (bool, error) {
+	var count int
+	err := q.QueryRow(dialect.rebind(dialect.hasTableQuery), table).Scan(&count)
+	return count > 0, err
+}
+
+func hasColumn(q queryer, dialect dialect, table, column string) (bool, error) {
+	var count int
+	err := q.QueryRow(dialect.rebind(dialect.hasColumnQuery), table, column).Scan(&count)
+	return count > 0, err
+}
+
+// runMigrateCommand выполняет служебную команду -migrate и печатает результат
+func runMigrateCommand(config DatabaseConfig, command string) error {
+	db, err := openDatabase(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case "status":
+		statuses, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			switch {
+			case !status.Applied:
+				fmt.Printf("%-30s ожидает применения\n", status.Name)
+			case status.AppliedAt.IsZero():
+				fmt.Printf("%-30s применена до появления schema_version\n", status.Name)
+			default:
+				fmt.Printf("%-30s применена %s\n", status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+		}
+	case "dry-run":
+		pending, err := db.DryRunMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("Схема базы данных актуальна, миграций для применения нет")
+			return nil
+		}
+		for _, m := range pending {
+			fmt.Printf("-- %s\n%s\n", m.Name, strings.TrimSpace(m.SQL))
+		}
+		fmt.Printf("Миграций к применению: %d (изменения отменены)\n", len(pending))
+	default:
+		return fmt.Errorf("неизвестная команда миграций %q, доступны status и dry-run", command)
+	}
+
+	return nil
+}
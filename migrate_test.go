@@ -0,0 +1,134 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"m/0002_second.sql": file("SELECT 2"),
+		"m/0001_first.sql":  file("SELECT 1"),
+		"m/README.md":       file("не миграция"),
+	}, "m")
+	if err != nil || len(migrations) != 2 || migrations[0].Name != "0001_first" || migrations[1].SQL != "SELECT 2" {
+		t.Fatalf("loadMigrations = %+v, %v", migrations, err)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"пропуск":      {"m/0001_a.sql": file(""), "m/0003_c.sql": file("")},
+		"повтор":       {"m/0001_a.sql": file(""), "m/0001_b.sql": file("")},
+		"без номера":   {"m/first.sql": file("")},
+		"нулевой":      {"m/0000_zero.sql": file("")},
+		"нет каталога": {},
+	} {
+		if _, err := loadMigrations(fsys, "m"); err == nil {
+			t.Errorf("%s: ошибка не возвращена", name)
+		}
+	}
+
+	// Встроенные миграции обеих СУБД идут без пропусков и с одинаковыми номерами
+	sqlite, err := loadMigrations(migrationFiles, sqliteDialect.migrationsDir)
+	if err != nil {
+		t.Fatalf("миграции SQLite: %v", err)
+	}
+	postgres, err := loadMigrations(migrationFiles, postgresDialect.migrationsDir)
+	if err != nil || len(postgres) != len(sqlite) {
+		t.Fatalf("миграций PostgreSQL %d, SQLite %d: %v", len(postgres), len(sqlite), err)
+	}
+	for i := range sqlite {
+		if sqlite[i].Name != postgres[i].Name {
+			t.Errorf("миграция %d: %s и %s", i+1, sqlite[i].Name, postgres[i].Name)
+		}
+	}
+}
+
+// Старая база без schema_version получает номер версии по колонкам и
+// дообновляется без потери данных
+func TestMigrateLegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "marketplace.db")
+	migrations, err := loadMigrations(migrationFiles, sqliteDialect.migrationsDir)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	config := DefaultDatabaseConfig()
+	config.DSN = path
+	legacy, err := openDatabase(config)
+	if err != nil {
+		t.Fatalf("openDatabase: %v", err)
+	}
+	for _, m := range migrations[:3] {
+		if _, err := legacy.db.Exec(m.SQL); err != nil {
+			t.Fatalf("%s: %v", m.Name, err)
+		}
+	}
+	if _, err := legacy.db.Exec(`INSERT INTO users (id, first_name) VALUES (1, 'Иван')`); err != nil {
+		t.Fatalf("пользователь: %v", err)
+	}
+	if _, err := legacy.db.Exec(`INSERT INTO devices (name, description, price, seller_id, seller_name, contact, category)
+		VALUES ('iPhone', 'Как новый', 1500.5, 1, 'Иван', '@ivan', 'smartphone')`); err != nil {
+		t.Fatalf("объявление: %v", err)
+	}
+	legacy.Close()
+
+	db := openTestDatabase(t, path)
+	statuses, err := db.MigrationStatus()
+	if err != nil || len(statuses) != len(migrations) {
+		t.Fatalf("MigrationStatus = %d, %v", len(statuses), err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt.IsZero() {
+			t.Errorf("миграция %s: %+v", status.Name, status)
+		}
+	}
+
+	devices, err := db.GetDevices()
+	if err != nil || len(devices) != 1 || devices[0].Price != 150050 || devices[0].Status != DeviceStatusActive {
+		t.Fatalf("объявления после миграции: %+v, %v", devices, err)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	for name, dsn := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			db := openTestDatabase(t, dsn)
+			migrations, err := loadMigrations(migrationFiles, db.dialect.migrationsDir)
+			if err != nil {
+				t.Fatalf("loadMigrations: %v", err)
+			}
+
+			broken := append(migrations,
+				migration{Version: len(migrations) + 1, Name: "next_ok", SQL: `CREATE TABLE migration_probe (id INTEGER)`},
+				migration{Version: len(migrations) + 2, Name: "next_broken", SQL: `SELECT * FROM no_such_table`})
+			if _, err := db.applyMigrations(broken, true); err == nil || !strings.Contains(err.Error(), "next_broken") {
+				t.Fatalf("applyMigrations: %v", err)
+			}
+
+			// Удачная миграция из той же партии тоже откатилась
+			if exists, err := hasTable(db.db, db.dialect, "migration_probe"); err != nil || exists {
+				t.Errorf("таблица из отмененной миграции: %v, %v", exists, err)
+			}
+			applied, err := appliedMigrations(db.db)
+			if err != nil || len(applied) != len(migrations) {
+				t.Fatalf("в schema_version %d записей, %v", len(applied), err)
+			}
+
+			// Dry-run показывает новые миграции, но ничего не записывает
+			pending, err := db.applyMigrations(broken[:len(migrations)+1], false)
+			if err != nil || len(pending) != 1 || pending[0].Name != "next_ok" {
+				t.Fatalf("dry-run = %+v, %v", pending, err)
+			}
+			if exists, _ := hasTable(db.db, db.dialect, "migration_probe"); exists {
+				t.Error("dry-run изменил схему")
+			}
+		})
+	}
+}
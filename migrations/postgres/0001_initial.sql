@@ -0,0 +1,22 @@
+-- Исходная схема маркетплейса
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT PRIMARY KEY,
+	first_name TEXT,
+	last_name TEXT,
+	username TEXT,
+	contact TEXT
+);
+
+CREATE TABLE IF NOT EXISTS devices (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT,
+	price DOUBLE PRECISION,
+	seller_id BIGINT REFERENCES users(id),
+	seller_name TEXT,
+	contact TEXT,
+	category TEXT
+);
+
+CREATE INDEX IF NOT EXISTS devices_seller_id_idx ON devices (seller_id);
+CREATE INDEX IF NOT EXISTS devices_category_idx ON devices (category);
@@ -0,0 +1,3 @@
+-- Статус публикации и причина отправки на модерацию
+ALTER TABLE devices ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE devices ADD COLUMN moderation_note TEXT NOT NULL DEFAULT '';
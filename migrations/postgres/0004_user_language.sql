@@ -0,0 +1,2 @@
+-- Язык интерфейса, выбранный пользователем
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT '';
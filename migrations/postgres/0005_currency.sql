@@ -0,0 +1,3 @@
+-- Валюта цены объявления и валюта отображения цен покупателю
+ALTER TABLE devices ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+ALTER TABLE users ADD COLUMN display_currency TEXT NOT NULL DEFAULT '';
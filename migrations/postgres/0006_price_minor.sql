@@ -0,0 +1,3 @@
+-- Цены в копейках вместо значений с плавающей точкой в рублях
+ALTER TABLE devices ADD COLUMN price_minor BIGINT NOT NULL DEFAULT 0;
+UPDATE devices SET price_minor = CAST(ROUND(COALESCE(price, 0) * 100) AS BIGINT);
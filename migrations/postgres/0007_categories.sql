@@ -0,0 +1,33 @@
+-- Категории каталога: администраторы редактируют их из бота командой /category
+CREATE TABLE IF NOT EXISTS categories (
+	code TEXT PRIMARY KEY,
+	emoji TEXT NOT NULL DEFAULT '',
+	sort_order INTEGER NOT NULL DEFAULT 0,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS category_names (
+	category_code TEXT NOT NULL REFERENCES categories(code) ON DELETE CASCADE,
+	language TEXT NOT NULL,
+	name TEXT NOT NULL,
+	PRIMARY KEY (category_code, language)
+);
+
+INSERT INTO categories (code, emoji, sort_order, enabled) VALUES
+	('smartphone', '📱', 1, TRUE),
+	('tablet', '📲', 2, TRUE),
+	('smartwatch', '⌚', 3, TRUE),
+	('accessory', '🎧', 4, TRUE),
+	('other', '📦', 5, TRUE);
+
+INSERT INTO category_names (category_code, language, name) VALUES
+	('smartphone', 'ru', 'Смартфоны'),
+	('smartphone', 'en', 'Smartphones'),
+	('tablet', 'ru', 'Планшеты'),
+	('tablet', 'en', 'Tablets'),
+	('smartwatch', 'ru', 'Умные часы'),
+	('smartwatch', 'en', 'Smartwatches'),
+	('accessory', 'ru', 'Аксессуары'),
+	('accessory', 'en', 'Accessories'),
+	('other', 'ru', 'Другое'),
+	('other', 'en', 'Other');
@@ -0,0 +1,52 @@
+-- Дерево каталога: категория → бренд → модель
+ALTER TABLE categories ADD COLUMN parent_code TEXT REFERENCES categories(code);
+
+CREATE INDEX IF NOT EXISTS categories_parent_code_idx ON categories (parent_code);
+
+-- Коды, которые администратор уже занял своими категориями, пропускаются
+INSERT INTO categories (code, parent_code, emoji, sort_order, enabled) VALUES
+	('smartphone_apple', 'smartphone', '', 1, TRUE),
+	('iphone_13', 'smartphone_apple', '', 1, TRUE),
+	('iphone_14', 'smartphone_apple', '', 2, TRUE),
+	('iphone_15', 'smartphone_apple', '', 3, TRUE),
+	('smartphone_samsung', 'smartphone', '', 2, TRUE),
+	('galaxy_s23', 'smartphone_samsung', '', 1, TRUE),
+	('galaxy_a54', 'smartphone_samsung', '', 2, TRUE),
+	('smartphone_xiaomi', 'smartphone', '', 3, TRUE),
+	('redmi_note_12', 'smartphone_xiaomi', '', 1, TRUE),
+	('tablet_apple', 'tablet', '', 1, TRUE),
+	('ipad', 'tablet_apple', '', 1, TRUE),
+	('ipad_air', 'tablet_apple', '', 2, TRUE),
+	('ipad_pro', 'tablet_apple', '', 3, TRUE),
+	('tablet_samsung', 'tablet', '', 2, TRUE),
+	('galaxy_tab_s9', 'tablet_samsung', '', 1, TRUE),
+	('smartwatch_apple', 'smartwatch', '', 1, TRUE),
+	('apple_watch_9', 'smartwatch_apple', '', 1, TRUE),
+	('apple_watch_se', 'smartwatch_apple', '', 2, TRUE),
+	('smartwatch_samsung', 'smartwatch', '', 2, TRUE),
+	('galaxy_watch_6', 'smartwatch_samsung', '', 1, TRUE)
+ON CONFLICT DO NOTHING;
+
+-- Названия брендов и моделей одинаковы на всех языках
+INSERT INTO category_names (category_code, language, name) VALUES
+	('smartphone_apple', 'ru', 'Apple'),
+	('iphone_13', 'ru', 'iPhone 13'),
+	('iphone_14', 'ru', 'iPhone 14'),
+	('iphone_15', 'ru', 'iPhone 15'),
+	('smartphone_samsung', 'ru', 'Samsung'),
+	('galaxy_s23', 'ru', 'Galaxy S23'),
+	('galaxy_a54', 'ru', 'Galaxy A54'),
+	('smartphone_xiaomi', 'ru', 'Xiaomi'),
+	('redmi_note_12', 'ru', 'Redmi Note 12'),
+	('tablet_apple', 'ru', 'Apple'),
+	('ipad', 'ru', 'iPad'),
+	('ipad_air', 'ru', 'iPad Air'),
+	('ipad_pro', 'ru', 'iPad Pro'),
+	('tablet_samsung', 'ru', 'Samsung'),
+	('galaxy_tab_s9', 'ru', 'Galaxy Tab S9'),
+	('smartwatch_apple', 'ru', 'Apple'),
+	('apple_watch_9', 'ru', 'Apple Watch Series 9'),
+	('apple_watch_se', 'ru', 'Apple Watch SE'),
+	('smartwatch_samsung', 'ru', 'Samsung'),
+	('galaxy_watch_6', 'ru', 'Galaxy Watch 6')
+ON CONFLICT DO NOTHING;
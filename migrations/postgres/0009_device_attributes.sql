@@ -0,0 +1,9 @@
+-- Характеристики объявлений по схеме категории: память, цвет, состояние аккумулятора
+CREATE TABLE IF NOT EXISTS device_attributes (
+	device_id INTEGER NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (device_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS device_attributes_name_value_idx ON device_attributes (name, value);
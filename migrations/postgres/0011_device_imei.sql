@@ -0,0 +1,3 @@
+-- IMEI устройства и результат его проверки
+ALTER TABLE devices ADD COLUMN imei TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN imei_status TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Индекс для проверки повторного IMEI по всем объявлениям, кроме отклоненных
+CREATE INDEX IF NOT EXISTS devices_imei_idx ON devices (imei);
@@ -0,0 +1,20 @@
+-- Исходная схема маркетплейса
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY,
+	first_name TEXT,
+	last_name TEXT,
+	username TEXT,
+	contact TEXT
+);
+
+CREATE TABLE IF NOT EXISTS devices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	description TEXT,
+	price REAL,
+	seller_id INTEGER,
+	seller_name TEXT,
+	contact TEXT,
+	category TEXT,
+	FOREIGN KEY (seller_id) REFERENCES users(id)
+);
@@ -0,0 +1,2 @@
+-- Время размещения для суточных лимитов объявлений
+ALTER TABLE devices ADD COLUMN created_at DATETIME;
@@ -0,0 +1,3 @@
+-- Цены в копейках вместо REAL-значений в рублях
+ALTER TABLE devices ADD COLUMN price_minor INTEGER NOT NULL DEFAULT 0;
+UPDATE devices SET price_minor = CAST(ROUND(COALESCE(price, 0) * 100) AS INTEGER);
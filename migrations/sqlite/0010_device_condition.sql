@@ -0,0 +1,5 @@
+-- Состояние устройства: оценка, дефекты и комплект через запятую, срок гарантии
+ALTER TABLE devices ADD COLUMN condition_grade TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN defects TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN kit TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN warranty_until DATE;
@@ -0,0 +1,7 @@
+-- Город и геопозиция объявления, город по умолчанию и последняя геопозиция пользователя
+ALTER TABLE devices ADD COLUMN city TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN latitude REAL;
+ALTER TABLE devices ADD COLUMN longitude REAL;
+ALTER TABLE users ADD COLUMN city TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN latitude REAL;
+ALTER TABLE users ADD COLUMN longitude REAL;
@@ -0,0 +1,3 @@
+-- Способы получения устройства и стоимость отправки в валюте объявления
+ALTER TABLE devices ADD COLUMN delivery TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN shipping_cost_minor INTEGER NOT NULL DEFAULT 0;
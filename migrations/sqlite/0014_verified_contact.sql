@@ -0,0 +1,3 @@
+-- Номер телефона, подтвержденный через Telegram, и отметка о подтвержденном контакте объявления
+ALTER TABLE users ADD COLUMN phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN contact_verified BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+-- Дата регистрации для профиля продавца и оценки продавцов покупателями. Для уже
+-- существующих пользователей датой регистрации считается первое объявление.
+ALTER TABLE users ADD COLUMN joined_at DATETIME;
+UPDATE users SET joined_at = (SELECT MIN(created_at) FROM devices WHERE devices.seller_id = users.id);
+
+CREATE TABLE IF NOT EXISTS seller_ratings (
+	seller_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	rater_id INTEGER NOT NULL,
+	score INTEGER NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (seller_id, rater_id)
+);
@@ -0,0 +1,10 @@
+-- Журнал размещений для суточного лимита объявлений: удаленное объявление
+-- не возвращает место в лимите. Время хранится в секундах Unix.
+CREATE TABLE IF NOT EXISTS listing_postings (
+	seller_id INTEGER NOT NULL,
+	posted_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS listing_postings_seller_idx ON listing_postings (seller_id, posted_at);
+
+INSERT INTO listing_postings (seller_id, posted_at)
+SELECT seller_id, CAST(strftime('%s', created_at) AS INTEGER) FROM devices WHERE created_at IS NOT NULL;
@@ -0,0 +1,61 @@
+package main
+
+import "time"
+
+type Device struct {
+	ID          int
+	Name        string
+	Description string
+	Price       Money
+	Currency    string
+	SellerID    int64
+	SellerName  string
+	Contact     string
+	Category    string
+	// Статус публикации: active, moderation, rejected или sold
+	Status         string
+	ModerationNote string
+	CreatedAt      time.Time
+	// Характеристики по схеме категории: storage_gb, color и т. д.
+	Attributes map[string]string
+	// Оценка состояния по шкале conditionGrades; пустая — продавец не указал
+	Condition string
+	// Коды отмеченных дефектов и предметов комплекта
+	Defects []string
+	Kit     []string
+	// Последний день гарантии; нулевое время — гарантии нет
+	WarrantyUntil time.Time
+	// IMEI виден только продавцу и модераторам; статус проверки — IMEIStatus*
+	IMEI       string
+	IMEIStatus string
+	// Код города из списка cities и точная геопозиция, если продавец ею поделился
+	City     string
+	Location GeoPoint
+	// Коды способов получения по списку deliveryMethods и стоимость отправки почтой
+	// в валюте объявления; нулевая стоимость при отправке — бесплатная доставка
+	Delivery     []string
+	ShippingCost Money
+	// Контакт совпадает с номером, подтвержденным через Telegram
+	ContactVerified bool
+}
+
+type User struct {
+	ID        int64
+	FirstName string
+	LastName  string
+	Username  string
+	// Контакт из последнего объявления — предлагается по умолчанию в следующем
+	Contact string
+	// Номер, которым пользователь поделился кнопкой request_contact, в виде +79001234567
+	Phone string
+	// Язык интерфейса, выбранный командой /language; пустой — определяется по клиенту Telegram
+	Language string
+	// Валюта, в которой покупатель хочет видеть цены; пустая — без пересчета
+	DisplayCurrency string
+	// Город по умолчанию и последняя отправленная геопозиция: по ним каталог
+	// фильтруется по городу и сортируется по расстоянию
+	City     string
+	Location GeoPoint
+	// Первое сохранение профиля; показывается в профиле продавца
+	JoinedAt time.Time
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	DeviceStatusActive     = "active"
+	DeviceStatusModeration = "moderation"
+	DeviceStatusRejected   = "rejected"
+	// Продавец отметил устройство проданным; в каталог оно больше не попадает,
+	// но учитывается в профиле продавца
+	DeviceStatusSold = "sold"
+)
+
+func formatDeviceStatus(lang string, device Device) string {
+	if device.Status == "" || device.Status == DeviceStatusActive {
+		return ""
+	}
+	return "\n" + T(lang, "status."+device.Status)
+}
+
+func formatModerationInfo(lang string, device Device) string {
+	view := newDeviceView(lang, device)
+	view.Card = template.HTML(formatDeviceInfo(lang, device))
+	return renderMessage(lang, "moderation_info", view)
+}
+
+// warnWithoutModerators предупреждает при запуске, что подозрительные объявления
+// уйдут на модерацию, но одобрить или отклонить их будет некому
+func warnWithoutModerators(config Config) {
+	if len(config.AdminIDs) == 0 {
+		log.Printf("Внимание: admin_ids пуст — объявления, отправленные проверками на модерацию, никто не рассмотрит")
+	}
+}
+
+func notifyModerators(sender *Sender, state *BotState, device Device) {
+	for _, adminID := range state.Config.AdminIDs {
+		lang := recipientLanguage(state, adminID)
+		msg := newHTMLMessage(adminID, formatModerationInfo(lang, device))
+		msg.ReplyMarkup = getModerationKeyboard(lang, device.ID)
+		sender.Send(msg)
+	}
+}
+
+// notifyModeratorsAboutBatch отправляет одно сообщение на весь пакет вместо карточки
+// на каждое объявление: сами объявления модераторы видят в /moderation
+func notifyModeratorsAboutBatch(sender *Sender, state *BotState, sellerName string, count int) {
+	for _, adminID := range state.Config.AdminIDs {
+		lang := recipientLanguage(state, adminID)
+		sender.Send(tgbotapi.NewMessage(adminID, TN(lang, "moderation.batch", count, sellerName)))
+	}
+}
+
+func getModerationKeyboard(lang string, deviceID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.approve"), fmt.Sprintf("approve_device_%d", deviceID)),
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.reject"), fmt.Sprintf("reject_device_%d", deviceID)),
+		),
+	)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money — сумма в минимальных единицах валюты (копейках, центах).
+// Целые числа не накапливают ошибок округления при сравнении и сортировке цен.
+type Money int64
+
+const minorUnits = 100
+
+var errInvalidMoney = errors.New("некорректная сумма")
+
+// ParseMoney разбирает сумму, введенную пользователем: «1500», «1 500,50», «99.9».
+// Допускается не больше двух знаков после запятой.
+func ParseMoney(text string) (Money, error) {
+	text = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(strings.TrimSpace(text))
+	if text == "" {
+		return 0, errInvalidMoney
+	}
+
+	units, fraction, hasFraction := strings.Cut(text, ".")
+	if units == "" || !isDigits(units) || (hasFraction && (len(fraction) > 2 || !isDigits(fraction))) {
+		return 0, errInvalidMoney
+	}
+
+	whole, err := strconv.ParseInt(units, 10, 64)
+	if err != nil || whole > math.MaxInt64/minorUnits-1 {
+		return 0, errInvalidMoney
+	}
+
+	var cents int64
+	if fraction != "" {
+		cents, _ = strconv.ParseInt(fraction, 10, 64)
+		if len(fraction) == 1 {
+			cents *= 10
+		}
+	}
+
+	return Money(whole*minorUnits + cents), nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromFloat округляет сумму в основных единицах до копеек
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * minorUnits))
+}
+
+func (m Money) Float() float64 {
+	return float64(m) / minorUnits
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/minorUnits, m%minorUnits)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want Money
+	}{
+		{"1500", 150000},
+		{" 1 500,50 ", 150050},
+		{"1 500", 150000},
+		{"99.9", 9990},
+		{"99,09", 9909},
+		{"0,05", 5},
+		{"12.", 1200},
+		{"007", 700},
+	} {
+		got, err := ParseMoney(c.text)
+		if err != nil || got != c.want {
+			t.Errorf("ParseMoney(%q) = %d, %v; ожидалось %d", c.text, got, err, c.want)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"   ",
+		"-100",
+		"+100",
+		"1.234",    // больше двух знаков после запятой
+		"1,5,0",    // две запятые
+		"1,234.56", // запятая как разделитель тысяч
+		",50",      // нет целой части
+		"1e3",
+		"сто",
+		"100₽",
+		strconv.FormatInt(math.MaxInt64/minorUnits, 10),
+		"99999999999999999999",
+	} {
+		if got, err := ParseMoney(text); err == nil {
+			t.Errorf("ParseMoney(%q) = %d, ожидалась ошибка", text, got)
+		}
+	}
+}
+
+func TestMoneyFormatting(t *testing.T) {
+	for _, c := range []struct {
+		money Money
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{150050, "1500.50"},
+		{-150, "-1.50"},
+	} {
+		if got := c.money.String(); got != c.want {
+			t.Errorf("Money(%d).String() = %q, ожидалось %q", c.money, got, c.want)
+		}
+	}
+
+	// Сумма с плавающей точкой округляется до копеек без накопленной ошибки
+	if got := MoneyFromFloat(0.1 + 0.2); got != 30 {
+		t.Errorf("MoneyFromFloat(0.1 + 0.2) = %d", got)
+	}
+	if got := MoneyFromFloat(19.999); got != 2000 || got.Float() != 20 {
+		t.Errorf("MoneyFromFloat(19.999) = %d", got)
+	}
+}
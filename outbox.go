@@ -0,0 +1,149 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// isTransientStorageError отличает временные сбои (занятая база, обрыв соединения,
+// конфликт сериализации) от ошибок, которые не исчезнут при повторе
+func isTransientStorageError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "40", "53", "57":
+			// Соединение, откат транзакции, нехватка ресурсов, остановка сервера
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// storageOutbox хранит в памяти записи, которые не удалось выполнить из-за временного
+// сбоя хранилища, и повторяет их в фоне в порядке поступления. Запись с тем же ключом
+// заменяет ожидающую, поэтому в базу попадает последнее состояние.
+type storageOutbox struct {
+	mu       sync.Mutex
+	db       *Database
+	pending  []outboxEntry
+	maxSize  int
+	interval time.Duration
+	seq      uint64
+}
+
+type outboxEntry struct {
+	key   string
+	write func(db *Database) error
+	seq   uint64
+}
+
+func newStorageOutbox(db *Database, interval time.Duration, maxSize int) *storageOutbox {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &storageOutbox{db: db, interval: interval, maxSize: maxSize}
+}
+
+func (o *storageOutbox) Enqueue(key string, write func(db *Database) error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq++
+	for i, entry := range o.pending {
+		if entry.key == key {
+			o.pending[i].write = write
+			o.pending[i].seq = o.seq
+			return
+		}
+	}
+
+	if o.maxSize > 0 && len(o.pending) >= o.maxSize {
+		log.Printf("Очередь отложенных записей переполнена, запись %s потеряна", o.pending[0].key)
+		o.pending = o.pending[1:]
+	}
+	o.pending = append(o.pending, outboxEntry{key: key, write: write, seq: o.seq})
+}
+
+// Has сообщает, ждет ли повтора запись с этим ключом: новые записи того же объекта
+// тогда тоже ставятся в очередь, чтобы не обогнать ожидающую
+func (o *storageOutbox) Has(key string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, entry := range o.pending {
+		if entry.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *storageOutbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+func (o *storageOutbox) Run() {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		o.flush()
+	}
+}
+
+// flush выполняет ожидающие записи по порядку и останавливается на первом временном сбое
+func (o *storageOutbox) flush() {
+	for {
+		o.mu.Lock()
+		if len(o.pending) == 0 {
+			o.mu.Unlock()
+			return
+		}
+		entry := o.pending[0]
+		o.mu.Unlock()
+
+		err := entry.write(o.db)
+		if isTransientStorageError(err) {
+			return
+		}
+		if err != nil {
+			log.Printf("Не удалось выполнить отложенную запись %s: %v", entry.key, err)
+		}
+
+		o.mu.Lock()
+		// Пока запись выполнялась, ее могли заменить более новой — тогда она остается в очереди
+		if len(o.pending) > 0 && o.pending[0].seq == entry.seq {
+			o.pending = o.pending[1:]
+		}
+		o.mu.Unlock()
+	}
+}
@@ -0,0 +1,74 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStorageOutboxRetriesTransientErrors(t *testing.T) {
+	outbox := newStorageOutbox(nil, time.Minute, 10)
+
+	attempts := 0
+	outbox.Enqueue("user:1", func(db *Database) error {
+		attempts++
+		if attempts == 1 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+
+	outbox.flush()
+	if outbox.Len() != 1 {
+		t.Fatalf("после временного сбоя в очереди %d записей, ожидалась 1", outbox.Len())
+	}
+	outbox.flush()
+	if outbox.Len() != 0 || attempts != 2 {
+		t.Fatalf("очередь = %d, попыток = %d", outbox.Len(), attempts)
+	}
+}
+
+func TestStorageOutboxKeepsLatestWritePerKey(t *testing.T) {
+	outbox := newStorageOutbox(nil, time.Minute, 10)
+
+	var written []string
+	outbox.Enqueue("user:1", func(db *Database) error { written = append(written, "старое"); return nil })
+	outbox.Enqueue("user:1", func(db *Database) error { written = append(written, "новое"); return nil })
+	outbox.Enqueue("user:2", func(db *Database) error { return errors.New("constraint failed") })
+
+	outbox.flush()
+	if len(written) != 1 || written[0] != "новое" {
+		t.Fatalf("выполнены записи %v", written)
+	}
+	if outbox.Len() != 0 {
+		t.Fatalf("постоянная ошибка оставила запись в очереди")
+	}
+}
+
+func TestAddDeviceWaitsForDeferredSellerProfile(t *testing.T) {
+	state := newTestBotState(t)
+
+	// Первая запись профиля упала на временном сбое и ждет повтора, поэтому
+	// следующий SaveUser тоже уходит в outbox, хотя профиль уже виден из памяти
+	state.outbox.Enqueue("user:7", func(db *Database) error { return driver.ErrBadConn })
+	if err := state.SaveUser(User{ID: 7, FirstName: "Иван"}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	if state.userStored(7) {
+		t.Fatal("профиль из outbox отмечен записанным")
+	}
+
+	device, err := state.AddDevice(Device{Name: "iPhone 13", SellerID: 7, SellerName: "Иван", Price: 100,
+		Currency: CurrencyRUB, Category: "iphone_13", Status: DeviceStatusActive})
+	if err != nil || device.ID == 0 {
+		t.Fatalf("AddDevice: %+v, %v", device, err)
+	}
+	users, err := state.db.GetUsers()
+	if err != nil || users[7].FirstName != "Иван" || users[7].JoinedAt.IsZero() || state.outbox.Len() != 0 {
+		t.Fatalf("продавец в базе: %+v, %v, в очереди %d", users[7], err, state.outbox.Len())
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	mu           sync.Mutex
+	rate         float64
+	capacity     float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func newTokenBucket(rate, capacity float64) *tokenBucket {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) Wait() {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+
+		if now.Before(b.blockedUntil) {
+			wait := b.blockedUntil.Sub(now)
+			b.mu.Unlock()
+			time.Sleep(wait)
+			continue
+		}
+
+		b.refill(now)
+		if b.tokens >= 1 || b.rate <= 0 {
+			b.tokens--
+			b.mu.Unlock()
+			return
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// Allow забирает токен без ожидания и сообщает, удалось ли это
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.blockedUntil) {
+		return false
+	}
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// Paused сообщает, действует ли еще пауза, выставленная Pause
+func (b *tokenBucket) Paused(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Before(b.blockedUntil)
+}
+
+func (b *tokenBucket) Pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
+
+const (
+	floodWarningInterval = time.Minute
+	floodIdleTimeout     = 10 * time.Minute
+	floodPruneEvery      = 1000
+)
+
+// FloodGuard ограничивает частоту входящих действий каждого пользователя
+type FloodGuard struct {
+	mu     sync.Mutex
+	config Config
+	users  map[int64]*floodState
+	calls  int
+}
+
+type floodState struct {
+	bucket   *tokenBucket
+	lastSeen time.Time
+	warnedAt time.Time
+}
+
+func NewFloodGuard(config Config) *FloodGuard {
+	return &FloodGuard{
+		config: config,
+		users:  make(map[int64]*floodState),
+	}
+}
+
+// Allow возвращает false, если действие нужно отбросить, и warn = true,
+// если пользователя пора предупредить (не чаще раза в минуту)
+func (g *FloodGuard) Allow(userID int64) (allowed bool, warn bool) {
+	limits := g.config.LimitsFor(userID)
+	if limits.UpdatesPerMinute <= 0 {
+		return true, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.calls++
+	if g.calls%floodPruneEvery == 0 {
+		g.prune(now)
+	}
+
+	user, ok := g.users[userID]
+	if !ok {
+		user = &floodState{bucket: newTokenBucket(float64(limits.UpdatesPerMinute)/60, float64(limits.UpdatesBurst))}
+		g.users[userID] = user
+	}
+	user.lastSeen = now
+
+	if user.bucket.Allow() {
+		return true, false
+	}
+
+	if now.Sub(user.warnedAt) >= floodWarningInterval {
+		user.warnedAt = now
+		return false, true
+	}
+	return false, false
+}
+
+func (g *FloodGuard) prune(now time.Time) {
+	for userID, user := range g.users {
+		if now.Sub(user.lastSeen) > floodIdleTimeout {
+			delete(g.users, userID)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(1, 3)
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("токен %d из запаса не выдан", i+1)
+		}
+	}
+	if bucket.Allow() {
+		t.Fatal("запас исчерпан, но токен выдан")
+	}
+
+	// За две секунды набираются два токена, но не больше емкости
+	bucket.last = bucket.last.Add(-2 * time.Second)
+	if !bucket.Allow() || !bucket.Allow() || bucket.Allow() {
+		t.Error("после двух секунд должно быть ровно два токена")
+	}
+	bucket.last = bucket.last.Add(-time.Hour)
+	bucket.refill(time.Now())
+	if bucket.tokens != 3 {
+		t.Errorf("токенов после долгого простоя: %v", bucket.tokens)
+	}
+
+	bucket.Pause(time.Minute)
+	if bucket.Allow() || !bucket.Paused(time.Now()) || bucket.Paused(time.Now().Add(2*time.Minute)) {
+		t.Error("пауза не действует")
+	}
+	// Более короткая пауза не сокращает уже выставленную
+	bucket.Pause(time.Second)
+	if !bucket.Paused(time.Now().Add(30 * time.Second)) {
+		t.Error("короткая пауза сократила длинную")
+	}
+
+	if bucket := newTokenBucket(1, 0); bucket.capacity != 1 || !bucket.Allow() {
+		t.Errorf("емкость меньше единицы: %+v", bucket)
+	}
+
+	// Без запаса Wait ждет, пока накопится следующий токен
+	bucket = newTokenBucket(100, 1)
+	bucket.Wait()
+	start := time.Now()
+	bucket.Wait()
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond || elapsed > time.Second {
+		t.Errorf("ожидание токена: %v", elapsed)
+	}
+}
+
+func TestFloodGuard(t *testing.T) {
+	config := DefaultConfig()
+	config.AdminIDs = []int64{1}
+	config.Limits[RoleUser] = RoleLimits{UpdatesPerMinute: 6, UpdatesBurst: 2}
+	guard := NewFloodGuard(config)
+
+	for _, c := range []struct {
+		name           string
+		allowed, warn  bool
+		sinceWarnedAgo time.Duration
+	}{
+		{"первое действие", true, false, 0},
+		{"второе действие", true, false, 0},
+		{"сверх запаса — предупреждение", false, true, 0},
+		{"повтор без предупреждения", false, false, 0},
+		{"предупреждение через минуту", false, true, floodWarningInterval},
+	} {
+		if c.sinceWarnedAgo > 0 {
+			guard.users[2].warnedAt = guard.users[2].warnedAt.Add(-c.sinceWarnedAgo)
+		}
+		if allowed, warn := guard.Allow(2); allowed != c.allowed || warn != c.warn {
+			t.Errorf("%s: allowed=%v warn=%v", c.name, allowed, warn)
+		}
+	}
+
+	// Лимиты считаются для каждого пользователя отдельно, у админа их нет
+	if allowed, _ := guard.Allow(3); !allowed {
+		t.Error("чужой лимит повлиял на другого пользователя")
+	}
+	for i := 0; i < 100; i++ {
+		if allowed, _ := guard.Allow(1); !allowed {
+			t.Fatal("админ ограничен")
+		}
+	}
+	if _, ok := guard.users[1]; ok {
+		t.Error("для пользователя без лимита заведено состояние")
+	}
+
+	guard.users[3].lastSeen = time.Now().Add(-floodIdleTimeout - time.Second)
+	guard.prune(time.Now())
+	if _, ok := guard.users[3]; ok || guard.users[2] == nil {
+		t.Errorf("prune: %v", guard.users)
+	}
+}
+
+func TestListingQuota(t *testing.T) {
+	config := DefaultConfig()
+	config.VerifiedSellerIDs = []int64{2}
+	config.Limits[RoleUser] = RoleLimits{ListingsPerDay: 3, MaxActiveListings: 4}
+	config.Limits[RoleVerified] = RoleLimits{}
+
+	for _, c := range []struct {
+		name  string
+		user  int64
+		usage listingUsage
+		left  int
+		quota string
+	}{
+		{"дневной лимит исчерпан", 1, listingUsage{Active: 3, CreatedToday: 3}, 0, "3 объявления"},
+		{"лимит активных исчерпан", 1, listingUsage{Active: 4, CreatedToday: 1}, 0, "4 активных"},
+		{"меньший из остатков", 1, listingUsage{Active: 1, CreatedToday: 1}, 2, ""},
+		{"без ограничений", 2, listingUsage{Active: 100, CreatedToday: 100}, -1, ""},
+	} {
+		if left := listingQuotaLeft(config, c.user, c.usage); left != c.left {
+			t.Errorf("%s: осталось %d, ожидалось %d", c.name, left, c.left)
+		}
+		got := checkListingQuota(LangRU, config, c.user, c.usage)
+		if (c.quota == "") != (got == "") || !strings.Contains(got, c.quota) {
+			t.Errorf("%s: %q", c.name, got)
+		}
+	}
+}
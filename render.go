@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сообщения с пользовательскими данными отправляются в режиме HTML.
+// html/template экранирует все подставляемые значения, поэтому символы
+// вроде <, > и & в названиях и описаниях не ломают разметку.
+var messageTemplates = map[string]map[string]string{
+	LangRU: templatesRU,
+	LangEN: templatesEN,
+}
+
+var renderers = mustParseMessageTemplates()
+
+func mustParseMessageTemplates() map[string]*template.Template {
+	result := make(map[string]*template.Template)
+	for lang, templates := range messageTemplates {
+		root, err := parseMessageTemplates(lang, templates)
+		if err != nil {
+			panic(err)
+		}
+		result[lang] = root
+	}
+	return result
+}
+
+func parseMessageTemplates(lang string, templates map[string]string) (*template.Template, error) {
+	root := template.New("messages").Funcs(template.FuncMap{
+		"price": formatPrice,
+		"t":     func(key string) string { return T(lang, key) },
+		"tn":    func(key string, n int) string { return TN(lang, key, n) },
+	})
+	for name, text := range templates {
+		if _, err := root.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("шаблон %s (%s): %v", name, lang, err)
+		}
+	}
+	return root, nil
+}
+
+// renderMessage возвращает готовый HTML-текст сообщения по имени шаблона
+func renderMessage(lang, name string, data interface{}) string {
+	renderer, ok := renderers[lang]
+	if !ok || renderer.Lookup(name) == nil {
+		renderer = renderers[defaultLanguage]
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Printf("Ошибка при подготовке сообщения %s: %v", name, err)
+		return ""
+	}
+	return buf.String()
+}
+
+func newHTMLMessage(chatID int64, text string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg
+}
+
+func formatPrice(price Money, currency string) string {
+	currency = normalizeCurrency(currency)
+	if currency == "" {
+		currency = baseCurrency
+	}
+	if symbol, ok := CurrencySymbols[currency]; ok {
+		return price.String() + " " + symbol
+	}
+	return price.String() + " " + currency
+}
+
+func categoryDisplayName(lang, category string) string {
+	path := categoryList.Path(category)
+	if len(path) == 0 {
+		return T(lang, "category.unknown")
+	}
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.Name(lang)
+	}
+	return strings.Join(names, " › ")
+}
+
+type deviceView struct {
+	Device       Device
+	CategoryName string
+	Attributes   []attributeView
+	// Состояние, дефекты и комплект — названиями на языке читателя
+	Condition string
+	Defects   string
+	Kit       string
+	// Срок гарантии, если она еще действует
+	Warranty     string
+	IMEIVerified bool
+	City         string
+	// Способы получения с ценой отправки
+	Delivery string
+	// Расстояние до покупателя, если список отсортирован по удаленности
+	Distance string
+	Card     template.HTML
+	// Цена, пересчитанная в валюту покупателя, если она отличается от валюты объявления
+	DisplayPrice string
+}
+
+func newDeviceView(lang string, device Device) deviceView {
+	view := deviceView{Device: device, CategoryName: categoryDisplayName(lang, device.Category), Attributes: attributeViews(lang, device)}
+	if device.Condition != "" {
+		view.Condition = T(lang, "condition."+device.Condition)
+		view.Defects = formatChecklist(lang, "defect.", device.Defects)
+	}
+	view.Kit = formatChecklist(lang, "kit.", device.Kit)
+	if hasWarranty(device, time.Now()) {
+		view.Warranty = device.WarrantyUntil.Format(warrantyDisplayLayout)
+	}
+	view.IMEIVerified = device.IMEIStatus == IMEIStatusVerified
+	view.City = cityName(lang, device.City)
+	view.Delivery = formatDelivery(lang, device)
+	return view
+}
+
+func formatDeviceInfo(lang string, device Device) string {
+	return renderMessage(lang, "device_card", newDeviceView(lang, device))
+}
+
+// formatDeviceForBuyer дополняет карточку ценой в валюте, выбранной покупателем
+func formatDeviceForBuyer(lang string, device Device, rates *CurrencyRates, displayCurrency string) string {
+	return renderMessage(lang, "device_card", newBuyerView(lang, device, rates, displayCurrency))
+}
+
+// formatDeviceNearBuyer добавляет к карточке покупателя расстояние до устройства
+func formatDeviceNearBuyer(lang string, device Device, rates *CurrencyRates, displayCurrency string, from GeoPoint) string {
+	view := newBuyerView(lang, device, rates, displayCurrency)
+	if point, ok := devicePoint(device); ok {
+		view.Distance = formatDistance(lang, distanceKm(from, point))
+	}
+	return renderMessage(lang, "device_card", view)
+}
+
+func newBuyerView(lang string, device Device, rates *CurrencyRates, displayCurrency string) deviceView {
+	view := newDeviceView(lang, device)
+	if displayCurrency != "" && normalizeCurrency(displayCurrency) != normalizeCurrency(device.Currency) {
+		if converted, ok := rates.Convert(device.Price, device.Currency, displayCurrency); ok {
+			view.DisplayPrice = formatPrice(converted, displayCurrency)
+		}
+	}
+	return view
+}
+
+func formatDeviceAdded(lang string, device Device) string {
+	return renderMessage(lang, "device_added", newDeviceView(lang, device))
+}
+
+func formatSearchHeader(lang, query string, count int) string {
+	data := struct {
+		Query string
+		Count int
+	}{strings.TrimSpace(query), count}
+
+	if count == 0 {
+		return renderMessage(lang, "search_empty", data)
+	}
+	return renderMessage(lang, "search_header", data)
+}
+
+func formatCategoryHeader(lang, category string, count int) string {
+	data := struct {
+		CategoryName string
+		Count        int
+	}{categoryDisplayName(lang, category), count}
+
+	if count == 0 {
+		return renderMessage(lang, "category_empty", data)
+	}
+	return renderMessage(lang, "category_header", data)
+}
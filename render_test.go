@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserTextIsEscaped(t *testing.T) {
+	device := Device{
+		ID:          1,
+		Name:        `<b>iPhone</b> & "Co"`,
+		Description: "<script>alert(1)</script> <a href=\"https://t.me\">ссылка</a>",
+		Price:       100000,
+		Currency:    CurrencyRUB,
+		Category:    CategorySmartphone,
+		Status:      DeviceStatusActive,
+		SellerName:  "<i>Иван</i>",
+		Contact:     "@ivan<br>",
+	}
+
+	for _, lang := range []string{LangRU, LangEN} {
+		for name, text := range map[string]string{
+			"карточка":         formatDeviceInfo(lang, device),
+			"добавление":       formatDeviceAdded(lang, device),
+			"заголовок поиска": formatSearchHeader(lang, "<b>iphone</b> & co", 3),
+			"пустой поиск":     formatSearchHeader(lang, "<b>iphone</b>", 0),
+			"профиль продавца": renderMessage(lang, "seller_profile", newSellerProfileView(lang, User{FirstName: "<i>Иван</i>"}, []Device{device}, SellerRating{}, false, 0)),
+		} {
+			if text == "" {
+				t.Errorf("%s (%s): пустое сообщение", name, lang)
+				continue
+			}
+			for _, raw := range []string{"<script>", "<a ", "<i>", "<br>", "<b>iPhone", "<b>iphone", `"Co"`, "& "} {
+				if strings.Contains(text, raw) {
+					t.Errorf("%s (%s): неэкранированный %q в %q", name, lang, raw, text)
+				}
+			}
+		}
+	}
+
+	card := formatDeviceInfo(LangRU, device)
+	for _, escaped := range []string{"&lt;b&gt;iPhone&lt;/b&gt; &amp; &#34;Co&#34;", "&lt;script&gt;alert(1)&lt;/script&gt;", "&lt;i&gt;Иван&lt;/i&gt;", "@ivan&lt;br&gt;"} {
+		if !strings.Contains(card, escaped) {
+			t.Errorf("в карточке нет %q: %q", escaped, card)
+		}
+	}
+	// Разметка самого шаблона остается
+	if !strings.HasPrefix(card, "📱 <b>&lt;b&gt;") {
+		t.Errorf("разметка шаблона: %q", card)
+	}
+}
+
+func TestMessageTemplatesMatchAcrossLanguages(t *testing.T) {
+	for name := range templatesRU {
+		if _, ok := templatesEN[name]; !ok {
+			t.Errorf("шаблона %s нет в английском переводе", name)
+		}
+	}
+	for name := range templatesEN {
+		if _, ok := templatesRU[name]; !ok {
+			t.Errorf("шаблона %s нет в русском переводе", name)
+		}
+	}
+}
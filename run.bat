@@ -1,5 +1,5 @@
-@echo off
-echo Запуск телеграм-бота маркетплейса мобильных устройств...
-set CGO_ENABLED=1
-go run main.go categories.go database.go
+@echo off
+echo Запуск телеграм-бота маркетплейса мобильных устройств...
+set CGO_ENABLED=1
+go run -tags withdb .
 pause 
\ No newline at end of file
@@ -1,4 +1,4 @@
-@echo off
-echo Запуск телеграм-бота маркетплейса мобильных устройств (без БД)...
-go run main_sqlite_disabled.go categories.go
+@echo off
+echo Запуск телеграм-бота маркетплейса мобильных устройств (без БД)...
+go run .
 pause 
\ No newline at end of file
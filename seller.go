@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Объявлений на одной странице профиля продавца
+const sellerPageSize = 5
+
+type SellerRating struct {
+	Average float64
+	Count   int
+}
+
+type sellerListingView struct {
+	Number int
+	Name   string
+	Price  string
+	City   string
+}
+
+type sellerProfileView struct {
+	Name     string
+	JoinedAt string
+	Active   int
+	Sold     int
+	Rating   string
+	// Отметки: продавец из verified_seller_ids и номер, подтвержденный через Telegram
+	VerifiedSeller bool
+	PhoneVerified  bool
+	Listings       []sellerListingView
+	Page           int
+	Pages          int
+	PageInfo       string
+}
+
+// newSellerProfileView собирает профиль по объявлениям продавца: активные идут
+// новыми вперед и делятся на страницы, проданные только считаются. Датой
+// регистрации считается более ранняя из даты профиля и первого объявления.
+func newSellerProfileView(lang string, seller User, devices []Device, rating SellerRating, verified bool, page int) sellerProfileView {
+	view := sellerProfileView{Name: seller.FirstName, VerifiedSeller: verified, PhoneVerified: seller.Phone != ""}
+
+	joined := seller.JoinedAt
+	var active []Device
+	for _, device := range devices {
+		switch device.Status {
+		case DeviceStatusActive:
+			active = append(active, device)
+		case DeviceStatusSold:
+			view.Sold++
+		}
+		if view.Name == "" {
+			view.Name = device.SellerName
+		}
+		if !device.CreatedAt.IsZero() && (joined.IsZero() || device.CreatedAt.Before(joined)) {
+			joined = device.CreatedAt
+		}
+	}
+	if view.Name == "" {
+		view.Name = T(lang, "seller.anonymous")
+	}
+	if !joined.IsZero() {
+		view.JoinedAt = joined.Format("02.01.2006")
+	}
+
+	view.Rating = T(lang, "seller.no_rating")
+	if rating.Count > 0 {
+		view.Rating = TN(lang, "seller.rating", rating.Count, strconv.FormatFloat(rating.Average, 'f', 1, 64))
+	}
+
+	view.Active = len(active)
+	view.Pages = (len(active) + sellerPageSize - 1) / sellerPageSize
+	if view.Pages == 0 {
+		view.Pages = 1
+	}
+	view.Page = min(max(page, 0), view.Pages-1)
+	if view.Pages > 1 {
+		view.PageInfo = T(lang, "seller.page", view.Page+1, view.Pages)
+	}
+
+	sort.SliceStable(active, func(i, j int) bool { return active[i].CreatedAt.After(active[j].CreatedAt) })
+	start := view.Page * sellerPageSize
+	for i, device := range active[start:min(start+sellerPageSize, len(active))] {
+		view.Listings = append(view.Listings, sellerListingView{
+			Number: start + i + 1,
+			Name:   device.Name,
+			Price:  formatPrice(device.Price, device.Currency),
+			City:   cityName(lang, device.City),
+		})
+	}
+	return view
+}
+
+// showSellerProfile показывает страницу профиля продавца новым сообщением или, если
+// задан messageID, обновляет уже показанный профиль при листании и после оценки
+func showSellerProfile(sender *Sender, state *BotState, lang string, chatID int64, messageID int, viewerID, sellerID int64, page int) {
+	devices, err := state.GetUserDevices(sellerID)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+	seller := state.GetUser(sellerID)
+	if seller.JoinedAt.IsZero() && len(devices) == 0 {
+		msg := tgbotapi.NewMessage(chatID, T(lang, "seller.not_found"))
+		msg.ReplyMarkup = getMainKeyboard(lang)
+		sender.Send(msg)
+		return
+	}
+	rating, err := state.GetSellerRating(sellerID)
+	if err != nil {
+		sendStorageError(sender, chatID, lang, err)
+		return
+	}
+
+	verified := state.Config.RoleOf(sellerID) == RoleVerified
+	view := newSellerProfileView(lang, seller, devices, rating, verified, page)
+	text := renderMessage(lang, "seller_profile", view)
+	keyboard := getSellerProfileKeyboard(lang, sellerID, view.Page, view.Pages, viewerID != sellerID)
+
+	if messageID == 0 {
+		msg := newHTMLMessage(chatID, text)
+		msg.ReplyMarkup = keyboard
+		sender.Send(msg)
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	sender.Send(edit)
+}
+
+// handleSellerCallback обрабатывает кнопки профиля: seller_<id> с карточки открывает
+// профиль, seller_<id>_<страница> листает его, rate_<id> и rate_<id>_<оценка> — оценка
+func handleSellerCallback(sender *Sender, callbackQuery *tgbotapi.CallbackQuery, state *BotState, lang string) {
+	userID := callbackQuery.From.ID
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+
+	if rest, ok := strings.CutPrefix(callbackQuery.Data, "rate_"); ok {
+		idText, scoreText, scored := strings.Cut(rest, "_")
+		sellerID, err := strconv.ParseInt(idText, 10, 64)
+		// Оценивать самого себя нельзя; кнопки для этого нет, но callback можно подделать
+		if err != nil || sellerID == userID {
+			return
+		}
+		if !scored {
+			sender.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, getRatingKeyboard(lang, sellerID)))
+			return
+		}
+		score, err := strconv.Atoi(scoreText)
+		if err != nil || score < 1 || score > 5 {
+			return
+		}
+		if err := state.RateSeller(sellerID, userID, score); err != nil {
+			sendStorageError(sender, chatID, lang, err)
+			return
+		}
+		showSellerProfile(sender, state, lang, chatID, messageID, userID, sellerID, 0)
+		return
+	}
+
+	idText, pageText, paged := strings.Cut(strings.TrimPrefix(callbackQuery.Data, "seller_"), "_")
+	sellerID, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil {
+		return
+	}
+	if !paged {
+		messageID = 0
+	}
+	page, _ := strconv.Atoi(pageText)
+	showSellerProfile(sender, state, lang, chatID, messageID, userID, sellerID, page)
+}
+
+func getSellerProfileKeyboard(lang string, sellerID int64, page, pages int, canRate bool) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.prev_page"), fmt.Sprintf("seller_%d_%d", sellerID, page-1)))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.next_page"), fmt.Sprintf("seller_%d_%d", sellerID, page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	if canRate {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.rate_seller"), fmt.Sprintf("rate_%d", sellerID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.to_main"), "back_to_main"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func getRatingKeyboard(lang string, sellerID int64) tgbotapi.InlineKeyboardMarkup {
+	var scores []tgbotapi.InlineKeyboardButton
+	for score := 1; score <= 5; score++ {
+		scores = append(scores, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d⭐", score), fmt.Sprintf("rate_%d_%d", sellerID, score)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		scores,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(T(lang, "button.back"), fmt.Sprintf("seller_%d_0", sellerID)),
+		),
+	)
+}
@@ -0,0 +1,44 @@
+//go:build withdb
+// +build withdb
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSellerRatingAndJoinedAt(t *testing.T) {
+	db := openTestDatabase(t, filepath.Join(t.TempDir(), "marketplace.db"))
+	joined := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.SaveUser(User{ID: 1, JoinedAt: joined}); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	// Повторное сохранение не сдвигает дату регистрации
+	if err := db.SaveUser(User{ID: 1, FirstName: "Иван", JoinedAt: joined.AddDate(1, 0, 0)}); err != nil {
+		t.Fatalf("повторный SaveUser: %v", err)
+	}
+	users, err := db.GetUsers()
+	if err != nil || !users[1].JoinedAt.Equal(joined) {
+		t.Fatalf("дата регистрации: %+v, %v", users[1], err)
+	}
+
+	rating, err := db.GetSellerRating(1)
+	if err != nil || rating.Count != 0 {
+		t.Fatalf("рейтинг без оценок: %+v, %v", rating, err)
+	}
+	for _, vote := range []struct {
+		rater int64
+		score int
+	}{{2, 5}, {3, 3}, {2, 4}} {
+		if err := db.RateSeller(1, vote.rater, vote.score, joined); err != nil {
+			t.Fatalf("RateSeller: %v", err)
+		}
+	}
+	// Повторная оценка покупателя 2 заменяет прежнюю: (4 + 3) / 2
+	rating, err = db.GetSellerRating(1)
+	if err != nil || rating.Count != 2 || rating.Average != 3.5 {
+		t.Fatalf("рейтинг: %+v, %v", rating, err)
+	}
+}
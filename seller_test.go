@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSellerProfileView(t *testing.T) {
+	joined := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var devices []Device
+	for i := 1; i <= 7; i++ {
+		devices = append(devices, Device{ID: i, Name: "iPhone", SellerName: "Иван", Price: Money(i * 100), Currency: CurrencyRUB,
+			Status: DeviceStatusActive, CreatedAt: joined.AddDate(0, 0, i)})
+	}
+	devices = append(devices,
+		Device{ID: 8, Status: DeviceStatusSold, CreatedAt: joined.AddDate(0, 0, -10)},
+		Device{ID: 9, Status: DeviceStatusModeration, CreatedAt: joined})
+
+	view := newSellerProfileView(LangRU, User{ID: 1, JoinedAt: joined}, devices, SellerRating{Average: 4.5, Count: 2}, true, 0)
+	if view.Name != "Иван" || view.Active != 7 || view.Sold != 1 || view.Pages != 2 || !view.VerifiedSeller || view.PhoneVerified {
+		t.Fatalf("профиль: %+v", view)
+	}
+	// Первое объявление старше профиля, поэтому дата регистрации берется из него
+	if view.JoinedAt != "20.02.2024" || view.Rating != "4.5 из 5 (2 оценки)" || view.PageInfo != "Страница 1 из 2" {
+		t.Errorf("профиль: %+v", view)
+	}
+	if len(view.Listings) != sellerPageSize || view.Listings[0].Price != formatPrice(700, CurrencyRUB) {
+		t.Errorf("первая страница: %+v", view.Listings)
+	}
+
+	view = newSellerProfileView(LangEN, User{ID: 1}, devices, SellerRating{}, false, 5)
+	if view.Page != 1 || len(view.Listings) != 2 || view.Listings[0].Number != 6 || view.Rating != "no ratings yet" {
+		t.Errorf("последняя страница: %+v", view)
+	}
+	if got := getSellerProfileKeyboard(LangRU, 1, view.Page, view.Pages, false); len(got.InlineKeyboard) != 2 || len(got.InlineKeyboard[0]) != 1 {
+		t.Errorf("клавиатура последней страницы: %+v", got.InlineKeyboard)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ограничения Telegram: около 30 сообщений в секунду на бота,
+// не больше одного сообщения в секунду в личный чат и 20 в минуту в группу
+type SenderConfig struct {
+	GlobalPerSecond float64 `json:"global_per_second"`
+	ChatPerSecond   float64 `json:"chat_per_second"`
+	GroupPerMinute  float64 `json:"group_per_minute"`
+	ChatBurst       int     `json:"chat_burst"`
+	MaxAttempts     int     `json:"max_attempts"`
+}
+
+func DefaultSenderConfig() SenderConfig {
+	return SenderConfig{
+		GlobalPerSecond: 30,
+		ChatPerSecond:   1,
+		GroupPerMinute:  20,
+		ChatBurst:       3,
+		MaxAttempts:     5,
+	}
+}
+
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+	senderIdleTimeout = 10 * time.Minute
+	senderPruneEvery  = 1000
+)
+
+// requester — часть BotAPI, через которую уходят запросы; в тестах ее заменяет заглушка
+type requester interface {
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// Sender ставит исходящие сообщения в очередь отдельного чата и отправляет их
+// с соблюдением лимитов Telegram. Порядок сообщений внутри чата сохраняется,
+// а ожидание retry_after в одном чате не задерживает остальные.
+type Sender struct {
+	bot        *tgbotapi.BotAPI
+	api        requester
+	config     SenderConfig
+	global     *tokenBucket
+	retryDelay time.Duration
+
+	mu    sync.Mutex
+	chats map[int64]*chatQueue
+	sends int
+	wg    sync.WaitGroup
+}
+
+// chatQueue живет и после опустошения очереди: иначе следующее сообщение получило бы
+// новый полный bucket и пауза после retry_after потерялась бы
+type chatQueue struct {
+	bucket   *tokenBucket
+	pending  []tgbotapi.Chattable
+	running  bool
+	lastUsed time.Time
+}
+
+func NewSender(bot *tgbotapi.BotAPI, config SenderConfig) *Sender {
+	return &Sender{
+		bot:        bot,
+		api:        bot,
+		config:     config,
+		global:     newTokenBucket(config.GlobalPerSecond, config.GlobalPerSecond),
+		retryDelay: initialRetryDelay,
+		chats:      make(map[int64]*chatQueue),
+	}
+}
+
+// BotUsername возвращает имя бота для ссылок t.me
+func (s *Sender) BotUsername() string {
+	if s.bot == nil {
+		return ""
+	}
+	return s.bot.Self.UserName
+}
+
+func (s *Sender) Send(c tgbotapi.Chattable) {
+	chatID := chattableChatID(c)
+
+	// Ответы на callback и прочие запросы без чата ограничиваются только общим
+	// лимитом: порядок для них не важен, и ждать друг друга им незачем
+	if chatID == 0 {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.deliver(chatID, nil, c)
+		}()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sends++
+	if s.sends%senderPruneEvery == 0 {
+		s.prune(now)
+	}
+
+	queue, ok := s.chats[chatID]
+	if !ok {
+		queue = &chatQueue{bucket: s.newChatBucket(chatID)}
+		s.chats[chatID] = queue
+	}
+	queue.pending = append(queue.pending, c)
+	queue.lastUsed = now
+
+	if !queue.running {
+		queue.running = true
+		s.wg.Add(1)
+		go s.drain(chatID, queue)
+	}
+}
+
+// Telegram отдает ботам файлы размером до 20 МБ
+const maxDownloadSize = 20 << 20
+
+// Download вызывается из цикла обработки обновлений, поэтому зависшее соединение
+// не должно останавливать бота
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// Download скачивает файл, присланный пользователем. Скачивание идет мимо очереди:
+// лимиты Telegram ограничивают только отправку сообщений.
+func (s *Sender) Download(fileID string) ([]byte, error) {
+	url, err := s.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := downloadClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("не удалось скачать файл: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("файл больше %d байт", maxDownloadSize)
+	}
+	return data, nil
+}
+
+// Flush дожидается отправки всех сообщений, поставленных в очередь
+func (s *Sender) Flush() {
+	s.wg.Wait()
+}
+
+func (s *Sender) newChatBucket(chatID int64) *tokenBucket {
+	burst := float64(s.config.ChatBurst)
+	if chatID < 0 {
+		return newTokenBucket(s.config.GroupPerMinute/60, burst)
+	}
+	return newTokenBucket(s.config.ChatPerSecond, burst)
+}
+
+func (s *Sender) drain(chatID int64, queue *chatQueue) {
+	defer s.wg.Done()
+
+	for {
+		s.mu.Lock()
+		if len(queue.pending) == 0 {
+			queue.running = false
+			queue.lastUsed = time.Now()
+			s.mu.Unlock()
+			return
+		}
+		c := queue.pending[0]
+		queue.pending = queue.pending[1:]
+		s.mu.Unlock()
+
+		s.deliver(chatID, queue.bucket, c)
+	}
+}
+
+// prune забывает чаты, в которые давно ничего не отправлялось; чат на паузе после
+// retry_after остается, пока пауза не истечет
+func (s *Sender) prune(now time.Time) {
+	for chatID, queue := range s.chats {
+		if !queue.running && now.Sub(queue.lastUsed) > senderIdleTimeout && !queue.bucket.Paused(now) {
+			delete(s.chats, chatID)
+		}
+	}
+}
+
+// deliver отправляет запрос с повторами. bucket == nil у запросов без чата: они
+// ждут только общий лимит, и retry_after для них относится ко всему боту.
+func (s *Sender) deliver(chatID int64, bucket *tokenBucket, c tgbotapi.Chattable) {
+	delay := s.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		if bucket != nil {
+			bucket.Wait()
+		}
+		s.global.Wait()
+
+		_, err := s.api.Request(c)
+		if err == nil {
+			return
+		}
+
+		var apiErr *tgbotapi.Error
+		isAPIError := errors.As(err, &apiErr)
+
+		switch {
+		case isAPIError && apiErr.Code == 429:
+			retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+			if retryAfter <= 0 {
+				retryAfter = delay
+			}
+			if bucket == nil {
+				log.Printf("Превышен общий лимит отправки, повтор через %v", retryAfter)
+				s.global.Pause(retryAfter)
+			} else {
+				log.Printf("Превышен лимит отправки в чат %d, повтор через %v", chatID, retryAfter)
+				bucket.Pause(retryAfter)
+			}
+		case isAPIError && apiErr.Code < 500:
+			log.Printf("Не удалось отправить сообщение в чат %d: %v", chatID, err)
+			return
+		default:
+			log.Printf("Временная ошибка отправки в чат %d (попытка %d): %v", chatID, attempt, err)
+			if bucket != nil {
+				bucket.Pause(delay)
+			} else {
+				time.Sleep(delay)
+			}
+			delay *= 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
+
+		if attempt >= s.config.MaxAttempts {
+			log.Printf("Сообщение в чат %d не отправлено после %d попыток: %v", chatID, attempt, err)
+			return
+		}
+	}
+}
+
+func chattableChatID(c tgbotapi.Chattable) int64 {
+	switch config := c.(type) {
+	case tgbotapi.MessageConfig:
+		return config.ChatID
+	case tgbotapi.DocumentConfig:
+		return config.ChatID
+	case tgbotapi.PhotoConfig:
+		return config.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return config.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return config.ChatID
+	case tgbotapi.DeleteMessageConfig:
+		return config.ChatID
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeRequester отвечает заранее заданными ошибками по порядку, а после них — успехом
+type fakeRequester struct {
+	mu     sync.Mutex
+	errs   []error
+	sent   map[int64][]string
+	called int
+}
+
+func (f *fakeRequester) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.called++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		if f.sent == nil {
+			f.sent = make(map[int64][]string)
+		}
+		f.sent[msg.ChatID] = append(f.sent[msg.ChatID], msg.Text)
+	}
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func newTestSender(api requester) *Sender {
+	sender := NewSender(nil, DefaultSenderConfig())
+	sender.api = api
+	sender.retryDelay = time.Millisecond
+	return sender
+}
+
+func TestSenderDeliver(t *testing.T) {
+	tooMany := &tgbotapi.Error{Code: 429, Message: "Too Many Requests"}
+	for _, c := range []struct {
+		name  string
+		errs  []error
+		calls int
+	}{
+		{"успех", nil, 1},
+		{"429 и повтор", []error{tooMany, tooMany}, 3},
+		{"4xx без повтора", []error{&tgbotapi.Error{Code: 403, Message: "Forbidden"}}, 1},
+		{"5xx и повтор", []error{&tgbotapi.Error{Code: 502, Message: "Bad Gateway"}}, 2},
+		{"сетевая ошибка и повтор", []error{errors.New("connection reset")}, 2},
+		{"попытки закончились", []error{
+			&tgbotapi.Error{Code: 500}, &tgbotapi.Error{Code: 500}, &tgbotapi.Error{Code: 500},
+			&tgbotapi.Error{Code: 500}, &tgbotapi.Error{Code: 500}, &tgbotapi.Error{Code: 500},
+		}, 5},
+	} {
+		api := &fakeRequester{errs: c.errs}
+		sender := newTestSender(api)
+		bucket := newTokenBucket(1000, 10)
+		sender.deliver(1, bucket, tgbotapi.NewMessage(1, "текст"))
+		if api.called != c.calls {
+			t.Errorf("%s: запросов %d, ожидалось %d", c.name, api.called, c.calls)
+		}
+	}
+}
+
+func TestSenderRetryAfterPausesBucket(t *testing.T) {
+	retry := &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 60}}
+
+	// Пауза из retry_after относится к чату, а не ко всему боту
+	sender := newTestSender(&fakeRequester{errs: []error{retry}})
+	sender.config.MaxAttempts = 1
+	bucket := newTokenBucket(1000, 10)
+	sender.deliver(1, bucket, tgbotapi.NewMessage(1, "текст"))
+	if now := time.Now(); !bucket.Paused(now) || sender.global.Paused(now) {
+		t.Error("retry_after в чате должен ставить на паузу только чат")
+	}
+
+	// У запросов без чата пауза ставится на общий лимит
+	sender = newTestSender(&fakeRequester{errs: []error{retry}})
+	sender.config.MaxAttempts = 1
+	sender.deliver(0, nil, tgbotapi.NewCallback("id", ""))
+	if !sender.global.Paused(time.Now()) {
+		t.Error("retry_after без чата должен ставить на паузу общий лимит")
+	}
+}
+
+func TestSenderKeepsOrderWithinChat(t *testing.T) {
+	api := &fakeRequester{errs: []error{&tgbotapi.Error{Code: 500}}}
+	sender := newTestSender(api)
+	sender.config.ChatBurst = 100
+	sender.config.ChatPerSecond = 1000
+
+	want := []string{"1", "2", "3", "4", "5"}
+	for _, text := range want {
+		sender.Send(tgbotapi.NewMessage(7, text))
+		sender.Send(tgbotapi.NewMessage(-100, text))
+	}
+	sender.Flush()
+
+	// Первое сообщение в один из чатов отправлялось дважды, но порядок не нарушился
+	if api.called != 11 {
+		t.Fatalf("запросов %d", api.called)
+	}
+	for _, chatID := range []int64{7, -100} {
+		if len(api.sent[chatID]) != len(want) {
+			t.Errorf("чат %d: %v", chatID, api.sent[chatID])
+			continue
+		}
+		for i := range api.sent[chatID] {
+			if api.sent[chatID][i] != want[i] {
+				t.Errorf("чат %d: %v", chatID, api.sent[chatID])
+				break
+			}
+		}
+	}
+	if len(sender.chats) != 2 || sender.chats[7].running || sender.chats[-100].running {
+		t.Errorf("очереди после Flush: %+v", sender.chats)
+	}
+}
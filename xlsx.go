@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Книга XLSX — zip-архив с XML-файлами. Для загрузки объявлений нужны только
+// значения ячеек первого листа, поэтому вместо внешней библиотеки разбираются
+// workbook.xml, связи листов, sharedStrings.xml и сам лист.
+
+// Ограничение на распакованный размер одной части книги защищает от zip-бомб
+const maxXLSXPartSize = 32 << 20
+
+type sheetRow struct {
+	// Номер строки, как его показывает Excel (заголовок — строка 1)
+	Num   int
+	Cells []string
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var builder strings.Builder
+	for _, run := range t.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Num   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSXRows(content []byte) ([]sheetRow, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("файл не является книгой XLSX: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]sheetRow, 0, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		num := row.Num
+		if num == 0 {
+			num = i + 1
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			col := xlsxColumnIndex(cell.Ref)
+			if col < 0 {
+				col = j
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("ячейка %s ссылается на несуществующую строку", cell.Ref)
+				}
+				cells[col] = shared.Items[index].String()
+			case "inlineStr":
+				cells[col] = cell.Inline.String()
+			case "", "n":
+				cells[col] = normalizeXLSXNumber(cell.Value)
+			default:
+				cells[col] = cell.Value
+			}
+		}
+		rows = append(rows, sheetRow{Num: num, Cells: cells})
+	}
+	return rows, nil
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("в книге нет листов")
+	}
+
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		// Путь задается относительно каталога xl/ или от корня архива
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("не найден файл первого листа")
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("в книге нет части %s", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("не удалось разобрать %s: %v", name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex переводит ссылку на ячейку (B7, AA12) в номер колонки с нуля
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
+
+// normalizeXLSXNumber убирает артефакты двоичного представления: Excel может
+// сохранить 14999,9 как 14999.899999999999. Excel хранит 15 значащих цифр.
+func normalizeXLSXNumber(value string) string {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(number, 'g', 15, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}